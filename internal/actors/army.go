@@ -1,15 +1,20 @@
 package actors
 
 import (
+	"cityio/internal/combat"
 	"cityio/internal/constants"
 	"cityio/internal/messages"
 	"cityio/internal/models"
+	"cityio/internal/pathfinding"
+	"cityio/internal/ws"
 
 	"log"
+	"math"
 	"sync"
 	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
+	"github.com/google/uuid"
 )
 
 type ArmyActor struct {
@@ -20,6 +25,8 @@ type ArmyActor struct {
 
 	armyOnce sync.Once
 
+	progress int // movement ticks spent towards entering the next tile of the route
+
 	ticker       *time.Ticker
 	stopTickerCh chan struct{}
 }
@@ -29,6 +36,14 @@ func (state *ArmyActor) Receive(ctx actor.Context) {
 
 	case messages.CreateArmyMessage:
 		state.Army = msg.Army
+		if state.Army.Kind == "" {
+			state.Army.Kind = constants.ARMY_KIND_TROOPS
+		}
+		// armies saved before troop types existed are made up of infantry
+		if len(state.Army.Troops) == 0 && state.Army.Kind == constants.ARMY_KIND_TROOPS {
+			state.Army.Troops = models.Troops{constants.TROOP_TYPE_INFANTRY: state.Army.Size}
+		}
+		state.Army.Size = state.Army.Troops.Total()
 
 		if !msg.Restore {
 			// default coordinates to (-1, -1) to distinguish from (0, 0) tile
@@ -60,8 +75,19 @@ func (state *ArmyActor) Receive(ctx actor.Context) {
 		ctx.Send(getTilePIDResponse.PID, messages.AddTileArmyMessage{
 			ArmyPID: ctx.Self(),
 			Army:    state.Army,
+			NoMerge: msg.NoMerge,
 		})
 
+		if state.Army.MarchActive {
+			// marches saved before routes were stored need to be planned again
+			if len(state.Army.Route) == 0 {
+				err := state.planRoute(state.Army.ToX, state.Army.ToY)
+				if err != nil {
+					log.Printf("Error restoring army march: %s", err)
+					state.endMarch()
+				}
+			}
+		}
 		if state.Army.MarchActive {
 			state.startTroopMovement(ctx)
 		}
@@ -83,6 +109,16 @@ func (state *ArmyActor) Receive(ctx actor.Context) {
 			Error: nil,
 		})
 
+	case messages.UpdateArmyTroopsMessage:
+		state.Army.Troops = msg.Troops
+		state.Army.Size = msg.Troops.Total()
+		ctx.Send(state.database, messages.UpdateArmyMessage{
+			Army: state.Army,
+		})
+		ctx.Respond(messages.UpdateArmyTroopsResponseMessage{
+			Error: nil,
+		})
+
 	case messages.DeleteArmyMessage:
 		ctx.Send(state.database, messages.DeleteArmyMessage{
 			ArmyId: state.Army.ArmyId,
@@ -96,8 +132,24 @@ func (state *ArmyActor) Receive(ctx actor.Context) {
 		ctx.Stop(ctx.Self())
 
 	case messages.StartArmyMarchMessage:
+		if state.Army.MarchActive {
+			ctx.Respond(messages.StartArmyMarchResponseMessage{
+				Error: &messages.ArmyAlreadyMarchingError{ArmyId: state.Army.ArmyId},
+			})
+			return
+		}
+
+		err := state.planRoute(msg.X, msg.Y)
+		if err != nil {
+			ctx.Respond(messages.StartArmyMarchResponseMessage{
+				Error: err,
+			})
+			return
+		}
+
 		// init background march operation
 		log.Printf("Army %s marching to (%d, %d)", state.Army.ArmyId, msg.X, msg.Y)
+		state.progress = 0
 		state.Army.FromX = state.Army.TileX
 		state.Army.FromY = state.Army.TileY
 		state.Army.ToX = msg.X
@@ -107,7 +159,63 @@ func (state *ArmyActor) Receive(ctx actor.Context) {
 		ctx.Send(state.database, messages.UpdateArmyMessage{
 			Army: state.Army,
 		})
+		state.updateTile(ctx)
 		state.startTroopMovement(ctx)
+		ctx.Respond(messages.StartArmyMarchResponseMessage{
+			Arrival: state.getArrival(),
+			Error:   nil,
+		})
+
+	case messages.RedirectArmyMarchMessage:
+		if !state.Army.MarchActive {
+			ctx.Respond(messages.RedirectArmyMarchResponseMessage{
+				Error: &messages.ArmyNotMarchingError{ArmyId: state.Army.ArmyId},
+			})
+			return
+		}
+
+		err := state.planRoute(msg.X, msg.Y)
+		if err != nil {
+			ctx.Respond(messages.RedirectArmyMarchResponseMessage{
+				Error: err,
+			})
+			return
+		}
+
+		log.Printf("Army %s redirected to (%d, %d)", state.Army.ArmyId, msg.X, msg.Y)
+		state.Army.FromX = state.Army.TileX
+		state.Army.FromY = state.Army.TileY
+		state.Army.ToX = msg.X
+		state.Army.ToY = msg.Y
+
+		ctx.Send(state.database, messages.UpdateArmyMessage{
+			Army: state.Army,
+		})
+		state.updateTile(ctx)
+		ctx.Respond(messages.RedirectArmyMarchResponseMessage{
+			Arrival: state.getArrival(),
+			Error:   nil,
+		})
+
+	case messages.CancelArmyMarchMessage:
+		if !state.Army.MarchActive {
+			ctx.Respond(messages.CancelArmyMarchResponseMessage{
+				Error: &messages.ArmyNotMarchingError{ArmyId: state.Army.ArmyId},
+			})
+			return
+		}
+
+		log.Printf("Army %s halted at (%d, %d)", state.Army.ArmyId, state.Army.TileX, state.Army.TileY)
+		err := state.halt(ctx)
+		ctx.Respond(messages.CancelArmyMarchResponseMessage{
+			Error: err,
+		})
+
+	case messages.PayArmyUpkeepMessage:
+		state.payUpkeep(ctx)
+
+	case messages.UnloadCaravanMessage:
+		state.unload(ctx, msg.Recipient, msg.Robbed, msg.Attempt)
 
 	// periodically called to update army position
 	case messages.UpdateArmyTileMessage:
@@ -115,6 +223,48 @@ func (state *ArmyActor) Receive(ctx actor.Context) {
 			state.stopPeriodicOperation()
 			return
 		}
+		if len(state.Army.Route) == 0 {
+			err := state.halt(ctx)
+			if err != nil {
+				log.Printf("Error halting army: %s", err)
+			}
+			return
+		}
+
+		// the army has not yet crossed the terrain of the next tile
+		next := state.Army.Route[0]
+		state.progress += state.getSpeed()
+		if state.progress < pathfinding.GetCost(next.X, next.Y)*constants.TROOP_MOVEMENT_PROGRESS {
+			return
+		}
+
+		// the route may have been cut off since it was planned, e.g. by a captured city
+		traveller, err := state.getTraveller()
+		if err != nil {
+			log.Printf("Error updating army tile: %s", err)
+			return
+		}
+		if !pathfinding.IsPassable(traveller, models.Point{X: state.Army.TileX, Y: state.Army.TileY}, next.X, next.Y, models.Point{X: state.Army.ToX, Y: state.Army.ToY}) {
+			err := state.planRoute(state.Army.ToX, state.Army.ToY)
+			if err != nil {
+				log.Printf("Army %s blocked at (%d, %d): %s", state.Army.ArmyId, state.Army.TileX, state.Army.TileY, err)
+				err = state.halt(ctx)
+				if err != nil {
+					log.Printf("Error halting army: %s", err)
+				}
+				// a caravan would never reach its destination, so the cargo goes back to the owner
+				if state.Army.Kind == constants.ARMY_KIND_CARAVAN {
+					state.unload(ctx, state.Army.Owner, false, 0)
+				}
+				return
+			}
+			log.Printf("Army %s rerouted to (%d, %d)", state.Army.ArmyId, state.Army.ToX, state.Army.ToY)
+			ctx.Send(state.database, messages.UpdateArmyMessage{
+				Army: state.Army,
+			})
+			state.updateTile(ctx)
+			return
+		}
 
 		tilePID, err := state.getTilePID()
 		if err != nil {
@@ -126,25 +276,14 @@ func (state *ArmyActor) Receive(ctx actor.Context) {
 			ArmyId: state.Army.ArmyId,
 		})
 
-		// move 1 at a time in the x direction, then y direction
-		if state.Army.TileX < state.Army.ToX {
-			state.Army.TileX++
-		} else if state.Army.TileX > state.Army.ToX {
-			state.Army.TileX--
-		} else if state.Army.TileY < state.Army.ToY {
-			state.Army.TileY++
-		} else if state.Army.TileY > state.Army.ToY {
-			state.Army.TileY--
-		}
+		state.progress = 0
+		state.Army.TileX = next.X
+		state.Army.TileY = next.Y
+		state.Army.Route = state.Army.Route[1:]
 		log.Printf("Army %s at (%d, %d)", state.Army.ArmyId, state.Army.TileX, state.Army.TileY)
 
-		if state.Army.TileX == state.Army.ToX && state.Army.TileY == state.Army.ToY {
-			state.stopPeriodicOperation()
-			state.Army.MarchActive = false
-			state.Army.FromX = -1
-			state.Army.FromY = -1
-			state.Army.ToX = -1
-			state.Army.ToY = -1
+		if len(state.Army.Route) == 0 {
+			state.endMarch()
 			ctx.Send(state.database, messages.UpdateArmyMessage{
 				Army: state.Army,
 			})
@@ -163,10 +302,10 @@ func (state *ArmyActor) Receive(ctx actor.Context) {
 }
 
 func (state *ArmyActor) startTroopMovement(ctx actor.Context) {
-	go func() {
-		state.ticker = time.NewTicker(constants.TROOP_MOVEMENT_DURATION * time.Second)
-		state.stopTickerCh = make(chan struct{})
+	state.ticker = time.NewTicker(constants.TROOP_MOVEMENT_DURATION * time.Second)
+	state.stopTickerCh = make(chan struct{})
 
+	go func() {
 		count := 0
 		for {
 			select {
@@ -188,6 +327,10 @@ func (state *ArmyActor) startTroopMovement(ctx actor.Context) {
 }
 
 func (state *ArmyActor) stopPeriodicOperation() {
+	if state.stopTickerCh == nil {
+		// army was never marching
+		return
+	}
 	select {
 	case <-state.stopTickerCh:
 	default:
@@ -195,6 +338,261 @@ func (state *ArmyActor) stopPeriodicOperation() {
 	}
 }
 
+// feeds the troops from the owner's food, troops left without food desert the army
+func (state *ArmyActor) payUpkeep(ctx actor.Context) {
+	var upkeep int64 = 0
+	for troopType, count := range state.Army.Troops {
+		upkeep += count * constants.GetTroopStats(troopType).Upkeep
+	}
+	if upkeep <= 0 {
+		return
+	}
+
+	ownerPID, err := state.getOwnerPID()
+	if err != nil {
+		log.Printf("Error paying army upkeep: %s", err)
+		return
+	}
+	consumeFoodResponse, err := Request[messages.ConsumeFoodResponseMessage](ctx, ownerPID, messages.ConsumeFoodMessage{
+		Amount: upkeep,
+		Reason: constants.LEDGER_REASON_UPKEEP,
+		Source: state.Army.ArmyId,
+	})
+	if err != nil {
+		log.Printf("Error paying army upkeep: %s", err)
+		return
+	}
+	if consumeFoodResponse.Consumed >= upkeep {
+		return
+	}
+
+	// rounded over the whole army, as flooring each troop type would wipe out the small ones
+	unfed := float64(upkeep-consumeFoodResponse.Consumed) / float64(upkeep)
+	deserters := int64(math.Round(float64(state.Army.Size) * unfed * constants.ARMY_DESERTION_RATE))
+	troops := combat.Remove(state.Army.Troops, deserters)
+	deserted := state.Army.Size - troops.Total()
+	log.Printf("Army %s is starving, %d troops deserted", state.Army.ArmyId, deserted)
+
+	state.Army.Troops = troops
+	state.Army.Size = troops.Total()
+	ws.Send(state.Army.Owner, messages.WS_STARVATION, &models.StarvationOutput{
+		ArmyId:    state.Army.ArmyId,
+		X:         state.Army.TileX,
+		Y:         state.Army.TileY,
+		Deserted:  deserted,
+		Remaining: state.Army.Size,
+	})
+
+	if state.Army.Size <= 0 {
+		state.disband(ctx)
+		return
+	}
+	ctx.Send(state.database, messages.UpdateArmyMessage{
+		Army: state.Army,
+	})
+	state.updateTile(ctx)
+}
+
+// shuts down an army that has no troops left. Unlike destroyArmy, this is done by the
+// army itself, so nothing may be requested from it
+func (state *ArmyActor) disband(ctx actor.Context) {
+	log.Printf("Army %s disbanded at (%d, %d)", state.Army.ArmyId, state.Army.TileX, state.Army.TileY)
+	state.stopPeriodicOperation()
+
+	tilePID, err := state.getTilePID()
+	if err != nil {
+		log.Printf("Error disbanding army: %s", err)
+	} else {
+		ctx.Send(tilePID, messages.RemoveTileArmyMessage{
+			Owner:  state.Army.Owner,
+			ArmyId: state.Army.ArmyId,
+		})
+	}
+
+	_, err = Request[messages.DeleteArmyPIDResponseMessage](ctx, GetManagerPID(), messages.DeleteArmyPIDMessage{
+		ArmyId: state.Army.ArmyId,
+	})
+	if err != nil {
+		log.Printf("Error disbanding army: %s", err)
+	}
+	ownerPID, err := state.getOwnerPID()
+	if err == nil {
+		ctx.Send(ownerPID, messages.RemoveUserArmyMessage{
+			ArmyId: state.Army.ArmyId,
+		})
+	}
+	ctx.Send(state.database, messages.DeleteArmyMessage{
+		ArmyId: state.Army.ArmyId,
+	})
+	ctx.Stop(ctx.Self())
+}
+
+// hands the cargo of a caravan over to the recipient, or to whoever robbed it on
+// the way, and disbands the caravan
+func (state *ArmyActor) unload(ctx actor.Context, recipient string, robbed bool, attempt int) {
+	if state.Army.Kind != constants.ARMY_KIND_CARAVAN {
+		return
+	}
+	gold := state.Army.CargoGold
+	food := state.Army.CargoFood
+	reason := constants.LEDGER_REASON_CARAVAN_DELIVERY
+	if robbed {
+		reason = constants.LEDGER_REASON_CARAVAN_ROBBERY
+	}
+
+	// the cargo stays on the caravan until the recipient has been paid
+	err := creditUser(ctx, recipient, messages.CreditResourcesMessage{
+		Gold:   gold,
+		Food:   food,
+		Reason: reason,
+		Source: state.Army.ArmyId,
+	})
+	if err != nil {
+		if attempt >= constants.CREDIT_RETRY_LIMIT {
+			log.Printf("Error unloading caravan %s, giving up: %s", state.Army.ArmyId, err)
+			return
+		}
+		log.Printf("Error unloading caravan %s, retrying: %s", state.Army.ArmyId, err)
+		self := ctx.Self()
+		time.AfterFunc(constants.CREDIT_RETRY_DELAY*time.Second, func() {
+			system.Root.Send(self, messages.UnloadCaravanMessage{
+				Recipient: recipient,
+				Robbed:    robbed,
+				Attempt:   attempt + 1,
+			})
+		})
+		return
+	}
+	state.Army.CargoGold = 0
+	state.Army.CargoFood = 0
+
+	if robbed {
+		log.Printf("Caravan %s robbed by %s at (%d, %d)", state.Army.ArmyId, recipient, state.Army.TileX, state.Army.TileY)
+	} else {
+		log.Printf("Caravan %s delivered to %s at (%d, %d)", state.Army.ArmyId, recipient, state.Army.TileX, state.Army.TileY)
+	}
+	delivery := &models.CaravanDeliveryOutput{
+		ArmyId:    state.Army.ArmyId,
+		X:         state.Army.TileX,
+		Y:         state.Army.TileY,
+		Recipient: recipient,
+		Gold:      gold,
+		Food:      food,
+		Robbed:    robbed,
+	}
+	ws.Send(state.Army.Owner, messages.WS_CARAVAN, delivery)
+	if recipient != state.Army.Owner {
+		ws.Send(recipient, messages.WS_CARAVAN, delivery)
+	}
+	state.disband(ctx)
+}
+
+// estimated time at which the army reaches its destination
+func (state *ArmyActor) getArrival() time.Time {
+	speed := state.getSpeed()
+	progress := state.progress
+	ticks := 0
+	for _, point := range state.Army.Route {
+		needed := pathfinding.GetCost(point.X, point.Y)*constants.TROOP_MOVEMENT_PROGRESS - progress
+		ticks += max(1, (needed+speed-1)/speed)
+		progress = 0
+	}
+	return time.Now().Add(time.Duration(ticks) * constants.TROOP_MOVEMENT_DURATION * time.Second)
+}
+
+// an army marches at the speed of its slowest troops
+func (state *ArmyActor) getSpeed() int {
+	if state.Army.Kind == constants.ARMY_KIND_CARAVAN {
+		return constants.CARAVAN_SPEED
+	}
+	speed := 0
+	for troopType, count := range state.Army.Troops {
+		if count <= 0 {
+			continue
+		}
+		troopSpeed := constants.GetTroopStats(troopType).Speed
+		if speed == 0 || troopSpeed < speed {
+			speed = troopSpeed
+		}
+	}
+	return max(1, speed)
+}
+
+// plans a route from the current tile to the destination and stores it on the army
+func (state *ArmyActor) planRoute(x int, y int) error {
+	traveller, err := state.getTraveller()
+	if err != nil {
+		return err
+	}
+	route, err := pathfinding.FindPath(traveller, models.Point{X: state.Army.TileX, Y: state.Army.TileY}, models.Point{X: x, Y: y})
+	if err != nil {
+		return err
+	}
+	state.Army.Route = route
+	return nil
+}
+
+func (state *ArmyActor) getTraveller() (pathfinding.Traveller, error) {
+	ownerPID, err := state.getOwnerPID()
+	if err != nil {
+		return pathfinding.Traveller{}, err
+	}
+	getUserResponse, err := Request[messages.GetUserResponseMessage](system.Root, ownerPID, messages.GetUserMessage{})
+	if err != nil {
+		return pathfinding.Traveller{}, err
+	}
+	return pathfinding.Traveller{
+		Owner:  state.Army.Owner,
+		Allies: getUserResponse.User.Allies,
+	}, nil
+}
+
+// stops the march and clears its details, persisting the army is left to the caller
+func (state *ArmyActor) endMarch() {
+	state.stopPeriodicOperation()
+	state.progress = 0
+	state.Army.MarchActive = false
+	state.Army.FromX = -1
+	state.Army.FromY = -1
+	state.Army.ToX = -1
+	state.Army.ToY = -1
+	state.Army.Route = []models.Point{}
+}
+
+// stops the army where it is and re-enters its tile as an idle army, same as arriving there
+func (state *ArmyActor) halt(ctx actor.Context) error {
+	state.endMarch()
+	ctx.Send(state.database, messages.UpdateArmyMessage{
+		Army: state.Army,
+	})
+
+	tilePID, err := state.getTilePID()
+	if err != nil {
+		return err
+	}
+	ctx.Send(tilePID, messages.RemoveTileArmyMessage{
+		Owner:  state.Army.Owner,
+		ArmyId: state.Army.ArmyId,
+	})
+	ctx.Send(tilePID, messages.AddTileArmyMessage{
+		ArmyPID: ctx.Self(),
+		Army:    state.Army,
+	})
+	return nil
+}
+
+// keeps the copy of the army held by its map tile in sync
+func (state *ArmyActor) updateTile(ctx actor.Context) {
+	tilePID, err := state.getTilePID()
+	if err != nil {
+		log.Printf("Error updating army tile: %s", err)
+		return
+	}
+	ctx.Send(tilePID, messages.UpdateTileArmyMessage{
+		Army: state.Army,
+	})
+}
+
 func (state *ArmyActor) getTilePID() (*actor.PID, error) {
 	getTilePIDResponse, err := Request[messages.GetMapTilePIDResponseMessage](system.Root, GetManagerPID(), messages.GetMapTilePIDMessage{
 		X: state.Army.TileX,
@@ -231,3 +629,124 @@ func (state *ArmyActor) getOwnerPID() (*actor.PID, error) {
 	}
 	return state.OwnerPID, nil
 }
+
+// destroyArmy shuts down an army actor and removes it from the pid manager and its owner.
+// Removing the army from its map tile is left to the caller.
+func destroyArmy(ctx actor.Context, armyPID *actor.PID, army models.Army) error {
+	deleteArmyResponse, err := Request[messages.DeleteArmyResponseMessage](ctx, armyPID, messages.DeleteArmyMessage{
+		ArmyId: army.ArmyId,
+	})
+	if err != nil {
+		log.Printf("Error destroying army: %s", err)
+		return err
+	}
+	if deleteArmyResponse.Error != nil {
+		log.Printf("Error destroying army: %s", deleteArmyResponse.Error)
+		return deleteArmyResponse.Error
+	}
+
+	deleteArmyPIDResponse, err := Request[messages.DeleteArmyPIDResponseMessage](ctx, GetManagerPID(), messages.DeleteArmyPIDMessage{
+		ArmyId: army.ArmyId,
+	})
+	if err != nil {
+		log.Printf("Error destroying army: %s", err)
+		return err
+	}
+	if deleteArmyPIDResponse.Error != nil {
+		log.Printf("Error destroying army: %s", deleteArmyPIDResponse.Error)
+		return deleteArmyPIDResponse.Error
+	}
+
+	getUserPIDResponse, err := Request[messages.GetUserPIDResponseMessage](ctx, GetManagerPID(), messages.GetUserPIDMessage{
+		UserId: army.Owner,
+	})
+	if err != nil {
+		log.Printf("Error destroying army: %s", err)
+		return err
+	}
+	if getUserPIDResponse.PID == nil {
+		return nil
+	}
+
+	removeUserArmyResponse, err := Request[messages.RemoveUserArmyResponseMessage](ctx, getUserPIDResponse.PID, messages.RemoveUserArmyMessage{
+		ArmyId: army.ArmyId,
+	})
+	if err != nil {
+		log.Printf("Error destroying army: %s", err)
+		return err
+	}
+	if removeUserArmyResponse.Error != nil {
+		log.Printf("Error destroying army: %s", removeUserArmyResponse.Error)
+		return removeUserArmyResponse.Error
+	}
+	return nil
+}
+
+// spawnArmy creates a new army actor and registers it with its owner and the pid manager.
+// Armies spawned with noMerge are kept apart from the other idle armies on their tile.
+func spawnArmy(ctx actor.Context, army models.Army, noMerge bool) (*actor.PID, error) {
+	getUserPIDResponse, err := Request[messages.GetUserPIDResponseMessage](ctx, GetManagerPID(), messages.GetUserPIDMessage{
+		UserId: army.Owner,
+	})
+	if err != nil {
+		log.Printf("Error creating army: %s", err)
+		return nil, err
+	}
+	if getUserPIDResponse.PID == nil {
+		log.Printf("Error creating army: User not found")
+		return nil, &messages.UserNotFoundError{UserId: army.Owner}
+	}
+
+	armyPID, err := Spawn(&ArmyActor{})
+	if err != nil {
+		log.Printf("Error creating army: %s", err)
+		return nil, err
+	}
+
+	if army.ArmyId == "" {
+		army.ArmyId = uuid.New().String()
+	}
+	army.Size = army.Troops.Total()
+	createArmyResponse, err := Request[messages.CreateArmyResponseMessage](ctx, armyPID, messages.CreateArmyMessage{
+		Army:    army,
+		Restore: false,
+		NoMerge: noMerge,
+	})
+	if err != nil {
+		log.Printf("Error creating army: %s", err)
+		return nil, err
+	}
+	if createArmyResponse.Error != nil {
+		log.Printf("Error creating army: %s", createArmyResponse.Error)
+		return nil, createArmyResponse.Error
+	}
+
+	addUserArmyResponse, err := Request[messages.AddUserArmyResponseMessage](ctx, getUserPIDResponse.PID, messages.AddUserArmyMessage{
+		ArmyId:  army.ArmyId,
+		ArmyPID: armyPID,
+	})
+	if err != nil {
+		log.Printf("Error creating army: %s", err)
+		return nil, err
+	}
+	if addUserArmyResponse.Error != nil {
+		log.Printf("Error creating army: %s", addUserArmyResponse.Error)
+		return nil, addUserArmyResponse.Error
+	}
+
+	addArmyPIDResponse, err := Request[messages.AddArmyPIDResponseMessage](ctx, GetManagerPID(), messages.AddArmyPIDMessage{
+		ArmyId: army.ArmyId,
+		PID:    armyPID,
+	})
+	if err != nil {
+		log.Printf("Error creating army: %s", err)
+		return nil, err
+	}
+	if addArmyPIDResponse.Error != nil {
+		log.Printf("Error creating army: %s", addArmyPIDResponse.Error)
+		return nil, addArmyPIDResponse.Error
+	}
+
+	log.Printf("Created army at (%d, %d) of size %d", army.TileX, army.TileY, army.Size)
+	return armyPID, nil
+}
@@ -4,9 +4,11 @@ import (
 	"cityio/internal/constants"
 	"cityio/internal/messages"
 	"cityio/internal/models"
+	"cityio/internal/ws"
 
 	"log"
 	"math"
+	"slices"
 	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
@@ -15,7 +17,7 @@ import (
 
 type BarracksActor struct {
 	BuildingActor
-	Training *models.Training
+	Trainings []models.Training // ordered training queue, only the first batch is being trained
 }
 
 func (state *BarracksActor) Receive(ctx actor.Context) {
@@ -27,216 +29,379 @@ func (state *BarracksActor) Receive(ctx actor.Context) {
 			ctx.Send(state.database, messages.CreateBuildingMessage{
 				Building: state.Building,
 			})
+			state.publish(&state.Building)
 		}
 		ctx.Respond(messages.CreateBuildingResponseMessage{
 			Error: nil,
 		})
 
-	case messages.UpgradeBuildingMessage:
-		ctx.Respond(messages.UpgradeBuildingResponseMessage{
-			Error: state.upgradeBuilding(ctx),
-		})
+	case messages.CompleteBuildingLevelMessage:
+		state.completeLevel(ctx, msg.Level)
 
 	case messages.RestoreTrainingMessage:
-		log.Printf("Restoring training %+v", msg.Training)
-		state.Training = &msg.Training
-		if state.Training.End.Before(time.Now()) {
-			state.completeTraining(ctx)
-		} else {
-			go state.backgroundTrain(ctx)
+		log.Printf("Restoring %d trainings for barracks %s", len(msg.Trainings), state.Building.BuildingId)
+		state.Trainings = msg.Trainings
+		slices.SortStableFunc(state.Trainings, func(a, b models.Training) int {
+			return a.Position - b.Position
+		})
+		if len(state.Trainings) > 0 && state.Trainings[0].Started {
+			// finished while the server was down if the end already passed
+			state.scheduleCompletion(ctx, state.Trainings[0])
 		}
+		state.updatePositions(ctx)
+		state.startNextTraining(ctx)
 		ctx.Respond(messages.RestoreTrainingResponseMessage{
 			Error: nil,
 		})
 
 	case messages.TrainTroopsMessage:
-		if state.Training != nil {
-			ctx.Respond(messages.TrainTroopsResponseMessage{
-				Error: &messages.TrainingAlreadyExistsError{BarracksId: state.Training.BarracksId},
-			})
-			return
-		}
-		endTime := time.Now().Add(time.Second * constants.TROOP_TRAINING_DURATION)
-		if state.Building.BuildingId != msg.Training.BarracksId {
-			ctx.Respond(messages.TrainTroopsResponseMessage{
-				Error: &messages.UnknownError{Message: "Requested barracks id does not match this building"},
-			})
-			return
-		}
-		state.Training = &models.Training{
-			BarracksId: state.Building.BuildingId,
-			Size:       msg.Training.Size,
-			DeployTo:   msg.Training.DeployTo,
-			End:        endTime,
-		}
-		log.Printf("Spawning traning of %d troops", state.Training.Size)
-		ctx.Send(GetDatabasePID(), messages.TrainTroopsMessage{
-			Training: *state.Training,
-		})
-		go state.backgroundTrain(ctx)
+		training, err := state.queueTraining(ctx, msg.Training)
 		ctx.Respond(messages.TrainTroopsResponseMessage{
-			Error: nil,
+			Training: training,
+			Error:    err,
+		})
+
+	case messages.CancelTrainingMessage:
+		ctx.Respond(messages.CancelTrainingResponseMessage{
+			Error: state.cancelTraining(ctx, msg.TrainingId),
 		})
 
+	case messages.ClearTrainingsMessage:
+		state.clearTrainings(ctx, msg.UserId)
+
+	case messages.GetTrainingsMessage:
+		ctx.Respond(messages.GetTrainingsResponseMessage{
+			Trainings: state.getTrainingOutputs(),
+		})
+
+	case messages.CompleteTrainingMessage:
+		state.completeTraining(ctx, msg.TrainingId)
+
 	case messages.GetBuildingMessage:
 		ctx.Respond(messages.GetBuildingResponseMessage{
 			Building: state.Building,
 		})
 
 	case messages.DeleteBuildingMessage:
+		// troops that were not trained yet are refunded like cancelled batches
+		for len(state.Trainings) > 0 {
+			state.cancelTraining(ctx, state.Trainings[len(state.Trainings)-1].TrainingId)
+		}
 		state.deleteBuilding(ctx)
 	}
 }
 
-func (state *BarracksActor) backgroundTrain(ctx actor.Context) {
-	time.Sleep(time.Until(state.Training.End))
-	state.completeTraining(ctx)
+// charges the owner for a batch of troops and adds it to the end of the queue
+func (state *BarracksActor) queueTraining(ctx actor.Context, request models.Training) (models.Training, error) {
+	if state.Building.Level < 1 {
+		return models.Training{}, &messages.BuildingUnderConstructionError{BuildingId: state.Building.BuildingId}
+	}
+	if state.Building.BuildingId != request.BarracksId {
+		return models.Training{}, &messages.InvalidRequestError{Reason: "barracks id does not match this building"}
+	}
+	if len(state.Trainings) >= constants.TRAINING_QUEUE_SIZE {
+		return models.Training{}, &messages.TrainingQueueFullError{BarracksId: state.Building.BuildingId, Size: constants.TRAINING_QUEUE_SIZE}
+	}
+	troopType := request.TroopType
+	if troopType == "" {
+		troopType = constants.TROOP_TYPE_INFANTRY
+	}
+	if !constants.IsTroopType(troopType) {
+		return models.Training{}, &messages.InvalidTroopTypeError{TroopType: troopType}
+	}
+	trainingId := uuid.New().String()
+	cost, err := state.chargeTraining(ctx, trainingId, troopType, request.Size)
+	if err != nil {
+		return models.Training{}, err
+	}
+
+	training := models.Training{
+		TrainingId: trainingId,
+		BarracksId: state.Building.BuildingId,
+		TroopType:  troopType,
+		Size:       request.Size,
+		DeployTo:   request.DeployTo,
+		Position:   len(state.Trainings),
+		Duration:   constants.GetTrainingDuration(troopType, request.Size, state.Building.Level),
+		GoldCost:   cost.Gold,
+		FoodCost:   cost.Food,
+	}
+	log.Printf("Queueing training of %d %s troops", training.Size, training.TroopType)
+	state.Trainings = append(state.Trainings, training)
+	ctx.Send(state.database, messages.TrainTroopsMessage{
+		Training: training,
+	})
+
+	state.startNextTraining(ctx)
+	state.wsTrainings()
+	return state.Trainings[state.findTraining(training.TrainingId)], nil
 }
 
-func (state *BarracksActor) completeTraining(ctx actor.Context) {
-	log.Printf("Training complete for %+v", state.Training)
-	ctx.Send(GetDatabasePID(), messages.DeleteTrainingMessage{
-		BarracksId: state.Training.BarracksId,
+// removes a batch from the queue, refunding it fully if it was still waiting
+// and partially if it was already being trained
+func (state *BarracksActor) cancelTraining(ctx actor.Context, trainingId string) error {
+	index := state.findTraining(trainingId)
+	if index == -1 {
+		return &messages.TrainingNotFoundError{TrainingId: trainingId}
+	}
+	training := state.Trainings[index]
+	state.Trainings = slices.Delete(state.Trainings, index, index+1)
+	ctx.Send(state.database, messages.DeleteTrainingMessage{
+		TrainingId: trainingId,
 	})
+
 	ownerId, err := state.getOwnerId()
+	if err == nil && ownerId != "" {
+		state.refundTraining(ctx, training, ownerId)
+	}
+
+	state.updatePositions(ctx)
+	state.startNextTraining(ctx)
+	state.wsTrainings()
+	return nil
+}
+
+// drops every queued batch, refunding it to the user that paid for it instead of
+// the current owner of the city
+func (state *BarracksActor) clearTrainings(ctx actor.Context, userId string) {
+	if len(state.Trainings) == 0 {
+		return
+	}
+	log.Printf("Clearing %d trainings of barracks %s", len(state.Trainings), state.Building.BuildingId)
+	for _, training := range state.Trainings {
+		ctx.Send(state.database, messages.DeleteTrainingMessage{
+			TrainingId: training.TrainingId,
+		})
+		if userId != "" {
+			state.refundTraining(ctx, training, userId)
+		}
+	}
+	state.Trainings = nil
+	state.wsTrainings()
+}
+
+// pays the costs of a removed batch back to the user, partially if it was already
+// being trained. Refunds are not capped by storage, the resources were already paid
+func (state *BarracksActor) refundTraining(ctx actor.Context, training models.Training, userId string) {
+	ratio := 1.0
+	if training.Started {
+		ratio = constants.TRAINING_REFUND_RATIO
+	}
+	err := creditUser(ctx, userId, messages.CreditResourcesMessage{
+		Gold:   int64(float64(training.GoldCost) * ratio),
+		Food:   int64(float64(training.FoodCost) * ratio),
+		Reason: constants.LEDGER_REASON_TRAINING_REFUND,
+		Source: training.TrainingId,
+	})
 	if err != nil {
-		log.Printf("Error completing training: %s", err)
+		log.Printf("Error refunding training %s: %s", training.TrainingId, err)
+	}
+}
+
+// starts the first batch of the queue once the previous one is done
+func (state *BarracksActor) startNextTraining(ctx actor.Context) {
+	if len(state.Trainings) == 0 || state.Trainings[0].Started {
 		return
 	}
+	training := &state.Trainings[0]
+	training.Started = true
+	training.End = time.Now().Add(time.Duration(training.Duration) * time.Second)
+	ctx.Send(state.database, messages.UpdateTrainingMessage{
+		Training: *training,
+	})
+	state.scheduleCompletion(ctx, *training)
+}
 
-	if state.Training.DeployTo != "" && state.Training.DeployTo != state.Building.CityId {
-		// TODO: do ownership verification checks of deployment city here at deploy time, not in api
-		getDeployCityPIDResponse, err := Request[messages.GetCityPIDResponseMessage](system.Root, GetManagerPID(), messages.GetCityPIDMessage{
-			CityId: state.Training.DeployTo,
+func (state *BarracksActor) scheduleCompletion(ctx actor.Context, training models.Training) {
+	go func() {
+		time.Sleep(time.Until(training.End))
+		ctx.Send(ctx.Self(), messages.CompleteTrainingMessage{
+			TrainingId: training.TrainingId,
 		})
-		// TODO: simplify error flow lol
-		if err != nil {
-			log.Printf("Error fetching deployment city pid after training, defaulting to same city barracks")
-			state.createArmy(ctx, models.Army{
-				TileX: state.Building.X,
-				TileY: state.Building.Y,
-				Owner: ownerId,
-				Size:  state.Training.Size,
-			})
-		}
-		if getDeployCityPIDResponse.PID == nil {
-			log.Printf("Error fetching deployment city pid after training, defaulting to same city barracks")
-			state.createArmy(ctx, models.Army{
-				TileX: state.Building.X,
-				TileY: state.Building.Y,
-				Owner: ownerId,
-				Size:  state.Training.Size,
-			})
-		}
+	}()
+}
 
-		var getDeployCityResponse *messages.GetCityResponseMessage
-		getDeployCityResponse, err = Request[messages.GetCityResponseMessage](ctx, getDeployCityPIDResponse.PID, messages.GetCityMessage{})
-		if err != nil {
-			log.Printf("Error fetching deployment city after training, defaulting to same city barracks")
-			state.createArmy(ctx, models.Army{
-				TileX: state.Building.X,
-				TileY: state.Building.Y,
-				Owner: ownerId,
-				Size:  state.Training.Size,
-			})
-		}
+func (state *BarracksActor) completeTraining(ctx actor.Context, trainingId string) {
+	index := state.findTraining(trainingId)
+	if index == -1 {
+		// cancelled while it was being trained
+		return
+	}
+	training := state.Trainings[index]
+	state.Trainings = slices.Delete(state.Trainings, index, index+1)
+	ctx.Send(state.database, messages.DeleteTrainingMessage{
+		TrainingId: trainingId,
+	})
 
-		cityX := getDeployCityResponse.City.StartX + int(math.Floor(float64(getDeployCityResponse.City.Size)/2))
-		cityY := getDeployCityResponse.City.StartY + int(math.Floor(float64(getDeployCityResponse.City.Size)/2))
-		log.Printf("Deploying to city at (%d, %d)", cityX, cityY)
-		state.createArmy(ctx, models.Army{
-			TileX: state.Building.X,
-			TileY: state.Building.Y,
-			Owner: ownerId,
-			Size:  state.Training.Size,
-
-			FromX:       state.Building.X,
-			FromY:       state.Building.Y,
-			ToX:         cityX,
-			ToY:         cityY,
-			MarchActive: true,
+	log.Printf("Training complete for %+v", training)
+	state.deployTroops(ctx, training)
+
+	ownerId, err := state.getOwnerId()
+	if err == nil && ownerId != "" {
+		ws.Send(ownerId, messages.WS_TRAINING_COMPLETE, &models.TrainingOutput{
+			Training: training,
+			Eta:      training.End,
 		})
+	}
+
+	state.updatePositions(ctx)
+	state.startNextTraining(ctx)
+	state.wsTrainings()
+}
+
+// spawns the trained troops in the city of the barracks, or sends them marching
+// to the requested city
+func (state *BarracksActor) deployTroops(ctx actor.Context, training models.Training) {
+	ownerId, err := state.getOwnerId()
+	if err != nil {
+		log.Printf("Error completing training: %s", err)
+		return
+	}
+
+	// the barracks itself is the fallback whenever the target city cannot be found
+	army := models.Army{
+		TileX:  state.Building.X,
+		TileY:  state.Building.Y,
+		Owner:  ownerId,
+		Troops: getTrainedTroops(training),
+	}
+
+	if training.DeployTo != "" && training.DeployTo != state.Building.CityId {
+		// TODO: do ownership verification checks of deployment city here at deploy time, not in api
+		deployCity, err := state.getDeployCity(ctx, training.DeployTo)
+		if err != nil {
+			log.Printf("Error fetching deployment city after training, defaulting to same city barracks: %s", err)
+		} else {
+			cityX := deployCity.StartX + int(math.Floor(float64(deployCity.Size)/2))
+			cityY := deployCity.StartY + int(math.Floor(float64(deployCity.Size)/2))
+			log.Printf("Deploying to city at (%d, %d)", cityX, cityY)
+			army.FromX = state.Building.X
+			army.FromY = state.Building.Y
+			army.ToX = cityX
+			army.ToY = cityY
+			army.MarchActive = true
+		}
 	} else {
 		cityPID := state.getCityPID()
 		if cityPID == nil {
 			log.Printf("Error fetching city pid after training, spawning in city barracks")
-			state.createArmy(ctx, models.Army{
-				TileX: state.Building.X,
-				TileY: state.Building.Y,
-				Owner: ownerId,
-				Size:  state.Training.Size,
-			})
-		}
-
-		getCityResponse, err := Request[messages.GetCityResponseMessage](ctx, cityPID, messages.GetCityMessage{})
-		if err != nil {
-			log.Printf("Error fetching city after training, spawning in city barracks")
-			state.createArmy(ctx, models.Army{
-				TileX: state.Building.X,
-				TileY: state.Building.Y,
-				Owner: ownerId,
-				Size:  state.Training.Size,
-			})
+		} else {
+			getCityResponse, err := Request[messages.GetCityResponseMessage](ctx, cityPID, messages.GetCityMessage{})
+			if err != nil {
+				log.Printf("Error fetching city after training, spawning in city barracks")
+			} else {
+				army.TileX = getCityResponse.City.StartX + int(math.Floor(float64(getCityResponse.City.Size)/2))
+				army.TileY = getCityResponse.City.StartY + int(math.Floor(float64(getCityResponse.City.Size)/2))
+			}
 		}
-		state.createArmy(ctx, models.Army{
-			TileX: getCityResponse.City.StartX + int(math.Floor(float64(getCityResponse.City.Size)/2)),
-			TileY: getCityResponse.City.StartY + int(math.Floor(float64(getCityResponse.City.Size)/2)),
-			Owner: ownerId,
-			Size:  state.Training.Size,
-		})
 	}
-	state.Training = nil
-}
 
-func (state *BarracksActor) createArmy(ctx actor.Context, army models.Army) error {
-	userPID := state.getUserPID()
-	if userPID == nil {
-		log.Printf("Error creating army: User not found")
-		return &messages.UserNotFoundError{UserId: army.Owner}
+	err = state.createArmy(ctx, army)
+	if err != nil {
+		log.Printf("Error creating trained army: %s", err)
 	}
+}
 
-	armyPID, err := Spawn(&ArmyActor{})
-
-	army.ArmyId = uuid.New().String()
-	createArmyResponse, err := Request[messages.CreateArmyResponseMessage](ctx, armyPID, messages.CreateArmyMessage{
-		Army:    army,
-		Restore: false,
+func (state *BarracksActor) getDeployCity(ctx actor.Context, cityId string) (models.City, error) {
+	getDeployCityPIDResponse, err := Request[messages.GetCityPIDResponseMessage](ctx, GetManagerPID(), messages.GetCityPIDMessage{
+		CityId: cityId,
 	})
 	if err != nil {
-		log.Printf("Error creating army: %s", err)
-		return err
+		return models.City{}, err
+	}
+	if getDeployCityPIDResponse.PID == nil {
+		return models.City{}, &messages.CityNotFoundError{CityId: cityId}
 	}
-	if createArmyResponse.Error != nil {
-		log.Printf("Error creating army: %s", createArmyResponse.Error)
-		return createArmyResponse.Error
+
+	getDeployCityResponse, err := Request[messages.GetCityResponseMessage](ctx, getDeployCityPIDResponse.PID, messages.GetCityMessage{})
+	if err != nil {
+		return models.City{}, err
 	}
+	return getDeployCityResponse.City, nil
+}
 
-	addUserArmyResponse, err := Request[messages.AddUserArmyResponseMessage](ctx, userPID, messages.AddUserArmyMessage{
-		ArmyId:  army.ArmyId,
-		ArmyPID: armyPID,
+// charges the owner of the barracks for training troops
+func (state *BarracksActor) chargeTraining(ctx actor.Context, trainingId string, troopType string, size int64) (constants.BuildingCost, error) {
+	if size <= 0 {
+		return constants.BuildingCost{}, &messages.InvalidTrainingSizeError{Size: size}
+	}
+	userPID := state.getUserPID()
+	if userPID == nil {
+		return constants.BuildingCost{}, &messages.UserNotFoundError{UserId: state.OwnerId}
+	}
+	stats := constants.GetTroopStats(troopType)
+	cost := constants.BuildingCost{
+		Gold: stats.Gold * size,
+		Food: stats.Food * size,
+	}
+	spendResponse, err := Request[messages.SpendResourcesResponseMessage](ctx, userPID, messages.SpendResourcesMessage{
+		Gold:   cost.Gold,
+		Food:   cost.Food,
+		Reason: constants.LEDGER_REASON_TRAINING,
+		Source: trainingId,
 	})
 	if err != nil {
-		log.Printf("Error creating army: %s", err)
-		return err
+		return constants.BuildingCost{}, err
 	}
-	if addUserArmyResponse.Error != nil {
-		log.Printf("Error creating army: %s", addUserArmyResponse.Error)
-		return addUserArmyResponse.Error
+	return cost, spendResponse.Error
+}
+
+// estimates when each batch will be done, they are trained one after another
+func (state *BarracksActor) getTrainingOutputs() []models.TrainingOutput {
+	outputs := make([]models.TrainingOutput, len(state.Trainings))
+	eta := time.Now()
+	for i, training := range state.Trainings {
+		if training.Started {
+			eta = training.End
+		} else {
+			eta = eta.Add(time.Duration(training.Duration) * time.Second)
+		}
+		outputs[i] = models.TrainingOutput{
+			Training: training,
+			Eta:      eta,
+		}
 	}
+	return outputs
+}
 
-	addArmyPIDResponse, err := Request[messages.AddArmyPIDResponseMessage](ctx, GetManagerPID(), messages.AddArmyPIDMessage{
-		ArmyId: army.ArmyId,
-		PID:    armyPID,
+func (state *BarracksActor) findTraining(trainingId string) int {
+	return slices.IndexFunc(state.Trainings, func(training models.Training) bool {
+		return training.TrainingId == trainingId
 	})
-	if err != nil {
-		log.Printf("Error creating army: %s", err)
-		return err
+}
+
+// persists the positions of batches that moved in the queue
+func (state *BarracksActor) updatePositions(ctx actor.Context) {
+	for i := range state.Trainings {
+		training := &state.Trainings[i]
+		if training.Position == i {
+			continue
+		}
+		training.Position = i
+		ctx.Send(state.database, messages.UpdateTrainingMessage{
+			Training: *training,
+		})
 	}
-	if addArmyPIDResponse.Error != nil {
-		log.Printf("Error creating army: %s", addArmyPIDResponse.Error)
-		return addArmyPIDResponse.Error
+}
+
+func (state *BarracksActor) wsTrainings() {
+	ownerId, err := state.getOwnerId()
+	if err != nil || ownerId == "" {
+		return
 	}
+	ws.Send(ownerId, messages.WS_TRAININGS, state.getTrainingOutputs())
+}
 
-	log.Printf("Created army at (%d, %d) of size %d", army.TileX, army.TileY, army.Size)
-	return nil
+func getTrainedTroops(training models.Training) models.Troops {
+	troopType := training.TroopType
+	// trainings saved before troop types existed are infantry
+	if troopType == "" {
+		troopType = constants.TROOP_TYPE_INFANTRY
+	}
+	return models.Troops{troopType: training.Size}
+}
+
+func (state *BarracksActor) createArmy(ctx actor.Context, army models.Army) error {
+	_, err := spawnArmy(ctx, army, false)
+	return err
 }
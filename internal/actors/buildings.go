@@ -4,6 +4,7 @@ import (
 	"cityio/internal/constants"
 	"cityio/internal/messages"
 	"cityio/internal/models"
+	"cityio/internal/ws"
 
 	"log"
 	"sync"
@@ -25,19 +26,15 @@ type BuildingActor struct {
 	tileOnce sync.Once
 }
 
-func (state *BuildingActor) upgradeBuilding(ctx actor.Context) error {
-	if state.Building.Level >= constants.MAX_BUILDING_LEVEL {
-		return &messages.MaxLevelReachedError{BuildingId: state.Building.BuildingId}
-	}
-
-	state.Building.Level++
-	state.Building.ConstructionEnd = state.Building.ConstructionEnd.Add(
-		time.Duration(constants.GetBuildingConstructionTime(state.Building.Type, state.Building.Level)) * time.Second,
-	)
+// applies a level finished by the construction queue of the city
+func (state *BuildingActor) completeLevel(ctx actor.Context, level int) {
+	state.Building.Level = level
+	state.Building.ConstructionEnd = time.Now()
 	ctx.Send(state.database, messages.UpdateBuildingMessage{
 		Building: state.Building,
 	})
-	return nil
+	log.Printf("Building %s of type %s reached level %d", state.Building.BuildingId, state.Building.Type, level)
+	state.publish(&state.Building)
 }
 
 func (state *BuildingActor) deleteBuilding(ctx actor.Context) {
@@ -47,10 +44,65 @@ func (state *BuildingActor) deleteBuilding(ctx actor.Context) {
 	ctx.Respond(messages.DeleteBuildingResponseMessage{
 		Error: nil,
 	})
+	state.publish(nil)
 	log.Printf("Shutting down BuildingActor of type %s at: (%d, %d)", state.Building.Type, state.Building.X, state.Building.Y)
 	ctx.Stop(ctx.Self())
 }
 
+// pushes the building to the players watching its tile, nil once it is gone
+func (state *BuildingActor) publish(building *models.Building) {
+	area := models.Viewport{X: state.Building.X, Y: state.Building.Y, Width: 1, Height: 1}
+	if !ws.IsWatched(area) {
+		return
+	}
+	ws.Publish(area, messages.WS_MAP_DELTA, models.MapTileDeltaOutput{
+		X:        state.Building.X,
+		Y:        state.Building.Y,
+		Event:    constants.MAP_EVENT_BUILDING,
+		Building: building,
+	})
+}
+
+// hands the resources yielded by this building to the owner of its city
+func (state *BuildingActor) produce(ctx actor.Context) {
+	// the first level is still being built
+	if state.Building.Level < 1 {
+		return
+	}
+
+	userPID := state.getUserPID()
+	if userPID == nil {
+		// not owned by a player
+		return
+	}
+	for resource, amount := range constants.GetBuildingProduction(state.Building.Type, state.Building.Level) {
+		switch resource {
+		case constants.RESOURCE_GOLD:
+			response, err := Request[messages.UpdateUserGoldResponseMessage](ctx, userPID, messages.UpdateUserGoldMessage{
+				Change: amount,
+				Reason: constants.LEDGER_REASON_PRODUCTION,
+				Source: state.Building.BuildingId,
+			})
+			if err != nil {
+				log.Printf("Error updating user gold: %s", err)
+			} else if response.Error != nil {
+				log.Printf("Error updating user gold: %s", response.Error)
+			}
+		case constants.RESOURCE_FOOD:
+			response, err := Request[messages.UpdateUserFoodResponseMessage](ctx, userPID, messages.UpdateUserFoodMessage{
+				Change: amount,
+				Reason: constants.LEDGER_REASON_PRODUCTION,
+				Source: state.Building.BuildingId,
+			})
+			if err != nil {
+				log.Printf("Error updating user food: %s", err)
+			} else if response.Error != nil {
+				log.Printf("Error updating user food: %s", response.Error)
+			}
+		}
+	}
+}
+
 func (state *BuildingActor) getCityPID() *actor.PID {
 	state.cityOnce.Do(func() {
 		response, err := Request[messages.GetCityPIDResponseMessage](system.Root, GetManagerPID(), messages.GetCityPIDMessage{
@@ -1,22 +1,38 @@
 package actors
 
 import (
+	"cityio/internal/combat"
 	"cityio/internal/constants"
 	"cityio/internal/messages"
 	"cityio/internal/models"
+	"cityio/internal/pathfinding"
+	"cityio/internal/ws"
 
 	"log"
+	"math"
 	"math/rand"
+	"slices"
 	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
 )
 
+type siege struct {
+	ArmyPID *actor.PID
+	Army    models.Army
+	End     time.Time
+}
+
 type CityActor struct {
 	BaseActor
 	City     models.City
 	TilePIDs map[int]map[int]*actor.PID
 	OwnerPID *actor.PID
+	Siege    *siege
+
+	Buildings     map[string]string       // building id to building type
+	BuildingTiles map[models.Point]string // tile to building id
+	Constructions []models.Construction   // ordered construction queue
 
 	ticker       *time.Ticker
 	stopTickerCh chan struct{}
@@ -29,6 +45,9 @@ func (state *CityActor) Receive(ctx actor.Context) {
 		state.City = msg.City
 		state.TilePIDs = msg.TilePIDs
 		state.OwnerPID = msg.OwnerPID
+		state.Buildings = make(map[string]string)
+		state.BuildingTiles = make(map[models.Point]string)
+		pathfinding.SetCity(state.City)
 
 		if !msg.Restore {
 			ctx.Send(state.database, messages.CreateCityMessage{
@@ -43,6 +62,93 @@ func (state *CityActor) Receive(ctx actor.Context) {
 	case messages.UpdateOwnerPIDMessage:
 		state.OwnerPID = msg.PID
 
+	case messages.UpdateCityTaxRateMessage:
+		if msg.TaxRate < 0 || msg.TaxRate > constants.MAX_TAX_RATE {
+			ctx.Respond(messages.UpdateCityTaxRateResponseMessage{
+				Error: &messages.InvalidTaxRateError{TaxRate: msg.TaxRate},
+			})
+			return
+		}
+		state.City.TaxRate = msg.TaxRate
+		ctx.Send(state.database, &messages.UpdateCityMessage{
+			City: state.City,
+		})
+		ctx.Respond(messages.UpdateCityTaxRateResponseMessage{
+			City:  state.City,
+			Error: nil,
+		})
+
+	case messages.AddCityBuildingMessage:
+		tile := models.Point{X: msg.X, Y: msg.Y}
+		if !msg.Restore {
+			err := state.checkBuildingPlacement(tile, msg.BuildingId, msg.BuildingType)
+			if err != nil {
+				ctx.Respond(messages.AddCityBuildingResponseMessage{
+					Error: err,
+				})
+				return
+			}
+		}
+		state.Buildings[msg.BuildingId] = msg.BuildingType
+		state.BuildingTiles[tile] = msg.BuildingId
+		ctx.Respond(messages.AddCityBuildingResponseMessage{
+			Error: nil,
+		})
+
+	case messages.RemoveCityBuildingMessage:
+		delete(state.Buildings, msg.BuildingId)
+		for tile, buildingId := range state.BuildingTiles {
+			if buildingId == msg.BuildingId {
+				delete(state.BuildingTiles, tile)
+			}
+		}
+		state.removeBuildingConstructions(ctx, msg.BuildingId)
+		ctx.Respond(messages.RemoveCityBuildingResponseMessage{
+			Error: nil,
+		})
+
+	case messages.GetCityBuildingsMessage:
+		buildings := make(map[string]string)
+		for buildingId, buildingType := range state.Buildings {
+			buildings[buildingId] = buildingType
+		}
+		ctx.Respond(messages.GetCityBuildingsResponseMessage{
+			Buildings: buildings,
+		})
+
+	case messages.QueueConstructionMessage:
+		construction, err := state.queueConstruction(ctx, msg.Building)
+		ctx.Respond(messages.QueueConstructionResponseMessage{
+			Construction: construction,
+			Error:        err,
+		})
+
+	case messages.CancelConstructionMessage:
+		demolishBuildingId, err := state.cancelConstruction(ctx, msg.ConstructionId)
+		ctx.Respond(messages.CancelConstructionResponseMessage{
+			DemolishBuildingId: demolishBuildingId,
+			Error:              err,
+		})
+
+	case messages.ReorderConstructionMessage:
+		ctx.Respond(messages.ReorderConstructionResponseMessage{
+			Error: state.reorderConstruction(ctx, msg.ConstructionId, msg.Position),
+		})
+
+	case messages.GetConstructionsMessage:
+		ctx.Respond(messages.GetConstructionsResponseMessage{
+			Constructions: state.getConstructionOutputs(),
+		})
+
+	case messages.RestoreConstructionsMessage:
+		state.restoreConstructions(ctx, msg.Constructions)
+		ctx.Respond(messages.RestoreConstructionsResponseMessage{
+			Error: nil,
+		})
+
+	case messages.CompleteConstructionMessage:
+		state.completeConstruction(ctx, msg.ConstructionId)
+
 	case messages.UpdateCityPopulationCapMessage:
 		if state.City.Owner != "" {
 			log.Println("Updating city population cap")
@@ -52,6 +158,42 @@ func (state *CityActor) Receive(ctx actor.Context) {
 			Error: nil,
 		})
 
+	case messages.StartSiegeMessage:
+		if state.Siege != nil {
+			return
+		}
+		centerX, centerY := state.getCenter()
+		if msg.X != centerX || msg.Y != centerY || msg.Army.Owner == state.City.Owner {
+			return
+		}
+		if state.OwnerPID != nil {
+			getOwnerResponse, err := Request[messages.GetUserResponseMessage](ctx, state.OwnerPID, messages.GetUserMessage{})
+			if err != nil {
+				log.Printf("Error starting siege: %s", err)
+				return
+			}
+			if slices.Contains(getOwnerResponse.User.Allies, msg.Army.Owner) {
+				return
+			}
+		}
+
+		state.Siege = &siege{
+			ArmyPID: msg.ArmyPID,
+			Army:    msg.Army,
+			End:     time.Now().Add(constants.SIEGE_DURATION * time.Second),
+		}
+		log.Printf("Army %s laying siege to %s", msg.Army.ArmyId, state.City.Name)
+		state.wsSiege(msg.Army.Owner, "started")
+
+		end := state.Siege.End
+		go func() {
+			time.Sleep(time.Until(end))
+			ctx.Send(ctx.Self(), messages.ResolveSiegeMessage{})
+		}()
+
+	case messages.ResolveSiegeMessage:
+		state.resolveSiege(ctx)
+
 	case messages.GetCityMessage:
 		ctx.Respond(messages.GetCityResponseMessage{
 			City: state.City,
@@ -72,14 +214,250 @@ func (state *CityActor) Receive(ctx actor.Context) {
 		currentPopulation := float64(state.City.Population)
 		populationCap := float64(state.City.PopulationCap)
 
-		newPopulation := currentPopulation + (constants.POPULATION_GROWTH_RATE)*currentPopulation*(1-currentPopulation/populationCap)
-		state.City.Population = newPopulation
+		fed := state.collectUpkeep(ctx)
+
+		newPopulation := currentPopulation + populationGrowthRate(currentPopulation, populationCap, state.City.TaxRate)*currentPopulation
+		if fed < 1 {
+			newPopulation = currentPopulation * (1 - (1-fed)*constants.POPULATION_STARVATION_RATE)
+		}
+		state.City.Population = math.Max(newPopulation, 0)
 		ctx.Send(state.database, &messages.UpdateCityMessage{
 			City: state.City,
 		})
 	}
 }
 
+// share of the population gained or lost in one tick. Higher taxes slow down growth, or
+// make people leave past the limit. The logistic term is negative over the cap, so its
+// sign must not flip leaving into growing
+func populationGrowthRate(population float64, populationCap float64, taxRate float64) float64 {
+	taxFactor := 1 - taxRate/constants.TAX_GROWTH_LIMIT
+	growthRate := constants.POPULATION_GROWTH_RATE * taxFactor * (1 - population/populationCap)
+	if taxFactor < 0 {
+		return -math.Abs(growthRate)
+	}
+	return growthRate
+}
+
+func (state *CityActor) resolveSiege(ctx actor.Context) {
+	siege := state.Siege
+	state.Siege = nil
+	if siege == nil {
+		return
+	}
+
+	centerX, centerY := state.getCenter()
+	getArmyResponse, err := Request[messages.GetArmyResponseMessage](ctx, siege.ArmyPID, messages.GetArmyMessage{})
+	if err != nil {
+		log.Printf("Siege of %s lifted: %s", state.City.Name, err)
+		state.wsSiege(siege.Army.Owner, "lifted")
+		return
+	}
+	army := getArmyResponse.Army
+	if army.MarchActive || army.TileX != centerX || army.TileY != centerY {
+		log.Printf("Siege of %s lifted: army %s left the city center", state.City.Name, army.ArmyId)
+		state.wsSiege(army.Owner, "lifted")
+		return
+	}
+
+	tilePID := state.TilePIDs[centerX-state.City.StartX][centerY-state.City.StartY]
+	// the garrison is raised from the population and fights as infantry
+	garrison := models.Troops{constants.TROOP_TYPE_INFANTRY: int64(state.City.Population * constants.CITY_GARRISON_RATIO)}
+	share, _ := combat.Resolve(combat.SiegeAttack(army.Troops), combat.Defense(garrison))
+	survivors := combat.Survivors(army.Troops, share)
+	if survivors.Total() == 0 {
+		log.Printf("Siege of %s failed, army %s destroyed", state.City.Name, army.ArmyId)
+		err = destroyArmy(ctx, siege.ArmyPID, army)
+		if err != nil {
+			log.Printf("Error destroying besieging army: %s", err)
+		}
+		ctx.Send(tilePID, messages.RemoveTileArmyMessage{
+			Owner:  army.Owner,
+			ArmyId: army.ArmyId,
+		})
+		state.wsSiege(army.Owner, "failed")
+		return
+	}
+
+	if survivors.Total() != army.Size {
+		army.Troops = survivors
+		army.Size = survivors.Total()
+		updateArmyTroopsResponse, err := Request[messages.UpdateArmyTroopsResponseMessage](ctx, siege.ArmyPID, messages.UpdateArmyTroopsMessage{
+			Troops: survivors,
+		})
+		if err != nil {
+			log.Printf("Error updating besieging army: %s", err)
+		} else if updateArmyTroopsResponse.Error != nil {
+			log.Printf("Error updating besieging army: %s", updateArmyTroopsResponse.Error)
+		}
+		ctx.Send(tilePID, messages.UpdateTileArmyMessage{
+			Army: army,
+		})
+	}
+
+	getOwnerPIDResponse, err := Request[messages.GetUserPIDResponseMessage](ctx, GetManagerPID(), messages.GetUserPIDMessage{
+		UserId: army.Owner,
+	})
+	if err != nil {
+		log.Printf("Error capturing city: %s", err)
+		return
+	}
+
+	state.wsSiege(army.Owner, "captured")
+
+	// the queue was paid for by the previous owner, who gets it refunded before losing the city
+	state.removeConstructions(ctx, func(models.Construction) bool {
+		return true
+	})
+	state.clearTrainings(ctx)
+
+	previousOwner := state.City.Owner
+	state.City.Owner = army.Owner
+	// a player only has a single capital, a captured one is demoted to a town
+	if state.City.Type == "capital" {
+		state.City.Type = "town"
+	}
+	pathfinding.SetCity(state.City)
+	ctx.Send(ctx.Self(), messages.UpdateOwnerPIDMessage{
+		PID: getOwnerPIDResponse.PID,
+	})
+	ctx.Send(state.database, &messages.UpdateCityMessage{
+		City: state.City,
+	})
+	log.Printf("City %s captured by %s", state.City.Name, army.Owner)
+
+	if previousOwner != "" {
+		ws.Send(previousOwner, messages.WS_CITY, &state.City)
+	}
+	ws.Send(army.Owner, messages.WS_CITY, &state.City)
+	state.publishOwner(ctx, getOwnerPIDResponse.PID)
+}
+
+// has the barracks of the city refund their queues to the owner losing it, troops
+// that were not trained yet do not change sides
+func (state *CityActor) clearTrainings(ctx actor.Context) {
+	for buildingId, buildingType := range state.Buildings {
+		if buildingType != constants.BUILDING_TYPE_BARRACKS {
+			continue
+		}
+		getBuildingPIDResponse, err := Request[messages.GetBuildingPIDResponseMessage](ctx, GetManagerPID(), messages.GetBuildingPIDMessage{
+			BuildingId: buildingId,
+		})
+		if err != nil {
+			log.Printf("Error clearing trainings of %s: %s", buildingId, err)
+			continue
+		}
+		if getBuildingPIDResponse.PID == nil {
+			log.Printf("Error clearing trainings of %s: %s", buildingId, &messages.BuildingNotFoundError{BuildingId: buildingId})
+			continue
+		}
+		ctx.Send(getBuildingPIDResponse.PID, messages.ClearTrainingsMessage{
+			UserId: state.City.Owner,
+		})
+	}
+}
+
+// pushes the city to the players watching any of its tiles, naming the owner like map tiles do
+func (state *CityActor) publishOwner(ctx actor.Context, ownerPID *actor.PID) {
+	area := models.Viewport{X: state.City.StartX, Y: state.City.StartY, Width: state.City.Size, Height: state.City.Size}
+	if !ws.IsWatched(area) || ownerPID == nil {
+		return
+	}
+	getUserResponse, err := Request[messages.GetUserResponseMessage](ctx, ownerPID, messages.GetUserMessage{})
+	if err != nil {
+		log.Printf("Error publishing city %s: %s", state.City.Name, err)
+		return
+	}
+
+	city := state.City
+	city.Owner = getUserResponse.User.Username
+	ws.Publish(area, messages.WS_MAP_DELTA, models.MapTileDeltaOutput{
+		X:     city.StartX,
+		Y:     city.StartY,
+		Event: constants.MAP_EVENT_CITY,
+		City:  &city,
+	})
+}
+
+// checks a new building against the buildings of the city, done here so that
+// concurrent constructions cannot both take the last slot of a type
+func (state *CityActor) checkBuildingPlacement(tile models.Point, buildingId string, buildingType string) error {
+	if occupant, ok := state.BuildingTiles[tile]; ok && occupant != buildingId {
+		return &messages.TileOccupiedError{X: tile.X, Y: tile.Y, BuildingId: occupant}
+	}
+	limit := constants.GetBuildingLimit(buildingType)
+	if limit == 0 {
+		return nil
+	}
+	count := 0
+	for _, otherType := range state.Buildings {
+		if otherType == buildingType {
+			count++
+		}
+	}
+	if count >= limit {
+		return &messages.BuildingLimitReachedError{CityId: state.City.CityId, BuildingType: buildingType, Limit: limit}
+	}
+	return nil
+}
+
+// feeds the population from the owner's food and collects their taxes.
+// Returns the share of the population that could be fed.
+func (state *CityActor) collectUpkeep(ctx actor.Context) float64 {
+	if state.City.Owner == "" || state.OwnerPID == nil {
+		return 1
+	}
+
+	food := int64(math.Ceil(state.City.Population * constants.POPULATION_FOOD_PER_CAPITA))
+	fed := 1.0
+	if food > 0 {
+		consumeFoodResponse, err := Request[messages.ConsumeFoodResponseMessage](ctx, state.OwnerPID, messages.ConsumeFoodMessage{
+			Amount: food,
+			Reason: constants.LEDGER_REASON_POPULATION,
+			Source: state.City.CityId,
+		})
+		if err != nil {
+			log.Printf("Error feeding population of %s: %s", state.City.Name, err)
+		} else {
+			fed = float64(consumeFoodResponse.Consumed) / float64(food)
+		}
+	}
+
+	tax := int64(state.City.Population * constants.TAX_PER_CAPITA * state.City.TaxRate)
+	if tax > 0 {
+		ctx.Send(state.OwnerPID, messages.UpdateUserGoldMessage{
+			Change: tax,
+			Reason: constants.LEDGER_REASON_TAX,
+			Source: state.City.CityId,
+		})
+	}
+	return fed
+}
+
+func (state *CityActor) getCenter() (int, int) {
+	return state.City.StartX + int(math.Floor(float64(state.City.Size)/2)),
+		state.City.StartY + int(math.Floor(float64(state.City.Size)/2))
+}
+
+func (state *CityActor) wsSiege(attacker string, status string) {
+	centerX, centerY := state.getCenter()
+	siege := &models.SiegeOutput{
+		CityId:   state.City.CityId,
+		Name:     state.City.Name,
+		X:        centerX,
+		Y:        centerY,
+		Attacker: attacker,
+		Status:   status,
+	}
+	if state.Siege != nil {
+		siege.End = state.Siege.End
+	}
+	ws.Send(attacker, messages.WS_SIEGE, siege)
+	if state.City.Owner != "" && state.City.Owner != attacker {
+		ws.Send(state.City.Owner, messages.WS_SIEGE, siege)
+	}
+}
+
 func (state *CityActor) startPeriodicOperation(ctx actor.Context) {
 	go func() {
 		// sleep for a random duration up to 10 seconds to attempt
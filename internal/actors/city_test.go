@@ -0,0 +1,33 @@
+package actors
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPopulationGrowthRate(t *testing.T) {
+	tests := []struct {
+		name       string
+		population float64
+		cap        float64
+		taxRate    float64
+		expected   float64
+	}{
+		{name: "untaxed below the cap", population: 500, cap: 1000, taxRate: 0, expected: 0.0005},
+		{name: "taxed below the cap", population: 500, cap: 1000, taxRate: 0.25, expected: 0.00025},
+		{name: "taxed at the growth limit", population: 500, cap: 1000, taxRate: 0.5, expected: 0},
+		{name: "taxed past the growth limit", population: 500, cap: 1000, taxRate: 1, expected: -0.0005},
+		{name: "at the cap", population: 1000, cap: 1000, taxRate: 0, expected: 0},
+		{name: "untaxed over the cap", population: 1500, cap: 1000, taxRate: 0, expected: -0.0005},
+		{name: "taxed past the growth limit over the cap", population: 1500, cap: 1000, taxRate: 1, expected: -0.0005},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rate := populationGrowthRate(test.population, test.cap, test.taxRate)
+			if math.Abs(rate-test.expected) > 1e-12 {
+				t.Errorf("populationGrowthRate(%v, %v, %v) = %v, expected %v", test.population, test.cap, test.taxRate, rate, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package actors
+
+import (
+	"cityio/internal/constants"
+	"cityio/internal/messages"
+	"cityio/internal/models"
+	"cityio/internal/ws"
+
+	"log"
+	"slices"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/google/uuid"
+)
+
+// queues the next level of a building, charging its cost to the owner of the city
+func (state *CityActor) queueConstruction(ctx actor.Context, building models.Building) (models.Construction, error) {
+	if len(state.Constructions) >= constants.CONSTRUCTION_QUEUE_SIZE {
+		return models.Construction{}, &messages.ConstructionQueueFullError{CityId: state.City.CityId, Size: constants.CONSTRUCTION_QUEUE_SIZE}
+	}
+
+	level := building.Level + 1
+	for _, construction := range state.Constructions {
+		if construction.BuildingId == building.BuildingId {
+			level++
+		}
+	}
+	if level > constants.MAX_BUILDING_LEVEL {
+		return models.Construction{}, &messages.MaxLevelReachedError{BuildingId: building.BuildingId}
+	}
+
+	// buildings in cities without an owner have nobody to pay for them
+	constructionId := uuid.New().String()
+	cost := constants.GetBuildingCost(building.Type, level)
+	if state.City.Owner != "" {
+		if state.OwnerPID == nil {
+			return models.Construction{}, &messages.UserNotFoundError{UserId: state.City.Owner}
+		}
+		spendResponse, err := Request[messages.SpendResourcesResponseMessage](ctx, state.OwnerPID, messages.SpendResourcesMessage{
+			Gold:   cost.Gold,
+			Food:   cost.Food,
+			Reason: constants.LEDGER_REASON_CONSTRUCTION,
+			Source: constructionId,
+		})
+		if err != nil {
+			return models.Construction{}, err
+		}
+		if spendResponse.Error != nil {
+			return models.Construction{}, spendResponse.Error
+		}
+	} else {
+		cost = constants.BuildingCost{}
+	}
+
+	construction := models.Construction{
+		ConstructionId: constructionId,
+		CityId:         state.City.CityId,
+		BuildingId:     building.BuildingId,
+		Type:           building.Type,
+		Level:          level,
+		X:              building.X,
+		Y:              building.Y,
+		Position:       len(state.Constructions),
+		Duration:       constants.GetBuildingConstructionTime(building.Type, level),
+		GoldCost:       cost.Gold,
+		FoodCost:       cost.Food,
+	}
+	state.Constructions = append(state.Constructions, construction)
+	ctx.Send(state.database, messages.CreateConstructionMessage{
+		Construction: construction,
+	})
+
+	state.scheduleConstructions(ctx)
+	state.wsConstructions()
+	return state.Constructions[state.findConstruction(construction.ConstructionId)], nil
+}
+
+// cancels a construction together with any later levels of the same building.
+// Returns the id of the building if its first level was cancelled, leaving nothing built.
+func (state *CityActor) cancelConstruction(ctx actor.Context, constructionId string) (string, error) {
+	index := state.findConstruction(constructionId)
+	if index == -1 {
+		return "", &messages.ConstructionNotFoundError{ConstructionId: constructionId}
+	}
+	cancelled := state.Constructions[index]
+
+	state.removeConstructions(ctx, func(construction models.Construction) bool {
+		return construction.BuildingId == cancelled.BuildingId && construction.Level >= cancelled.Level
+	})
+
+	if cancelled.Level == 1 {
+		return cancelled.BuildingId, nil
+	}
+	return "", nil
+}
+
+// moves a construction that has not started yet to another position in the queue
+func (state *CityActor) reorderConstruction(ctx actor.Context, constructionId string, position int) error {
+	index := state.findConstruction(constructionId)
+	if index == -1 {
+		return &messages.ConstructionNotFoundError{ConstructionId: constructionId}
+	}
+	construction := state.Constructions[index]
+	if construction.Started {
+		return &messages.ConstructionInProgressError{ConstructionId: constructionId}
+	}
+	if position < 0 || position >= len(state.Constructions) {
+		return &messages.InvalidQueuePositionError{ConstructionId: constructionId, Position: position}
+	}
+
+	reordered := slices.Delete(slices.Clone(state.Constructions), index, index+1)
+	reordered = slices.Insert(reordered, position, construction)
+	// levels of a building have to stay in order
+	previousLevel := 0
+	for _, other := range reordered {
+		if other.BuildingId != construction.BuildingId {
+			continue
+		}
+		if other.Level < previousLevel {
+			return &messages.InvalidQueuePositionError{ConstructionId: constructionId, Position: position}
+		}
+		previousLevel = other.Level
+	}
+
+	state.Constructions = reordered
+	state.updatePositions(ctx)
+	state.scheduleConstructions(ctx)
+	state.wsConstructions()
+	return nil
+}
+
+// drops the constructions of a building that is being demolished
+func (state *CityActor) removeBuildingConstructions(ctx actor.Context, buildingId string) {
+	state.removeConstructions(ctx, func(construction models.Construction) bool {
+		return construction.BuildingId == buildingId
+	})
+}
+
+// removes the matching constructions from the queue and refunds them, fully if they
+// had not started yet and partially otherwise
+func (state *CityActor) removeConstructions(ctx actor.Context, match func(models.Construction) bool) {
+	removed := false
+	state.Constructions = slices.DeleteFunc(state.Constructions, func(construction models.Construction) bool {
+		if !match(construction) {
+			return false
+		}
+		ratio := 1.0
+		if construction.Started {
+			ratio = constants.CONSTRUCTION_REFUND_RATIO
+		}
+		if state.OwnerPID != nil {
+			// the costs were already paid, so the refund is not capped by storage
+			creditResponse, err := Request[messages.CreditResourcesResponseMessage](ctx, state.OwnerPID, messages.CreditResourcesMessage{
+				Gold:   int64(float64(construction.GoldCost) * ratio),
+				Food:   int64(float64(construction.FoodCost) * ratio),
+				Reason: constants.LEDGER_REASON_CONSTRUCTION_REFUND,
+				Source: construction.ConstructionId,
+			})
+			if err == nil {
+				err = creditResponse.Error
+			}
+			if err != nil {
+				log.Printf("Error refunding construction %s: %s", construction.ConstructionId, err)
+			}
+		}
+		ctx.Send(state.database, messages.DeleteConstructionMessage{
+			ConstructionId: construction.ConstructionId,
+		})
+		removed = true
+		return true
+	})
+	if !removed {
+		return
+	}
+
+	state.updatePositions(ctx)
+	state.scheduleConstructions(ctx)
+	state.wsConstructions()
+}
+
+func (state *CityActor) restoreConstructions(ctx actor.Context, constructions []models.Construction) {
+	state.Constructions = constructions
+	slices.SortStableFunc(state.Constructions, func(a, b models.Construction) int {
+		return a.Position - b.Position
+	})
+	for _, construction := range state.Constructions {
+		if construction.Started {
+			// finished while the server was down if the end already passed
+			state.scheduleCompletion(ctx, construction)
+		}
+	}
+	state.updatePositions(ctx)
+	state.scheduleConstructions(ctx)
+}
+
+// hands a finished level to its building and starts the next constructions
+func (state *CityActor) completeConstruction(ctx actor.Context, constructionId string) {
+	index := state.findConstruction(constructionId)
+	if index == -1 {
+		// cancelled while it was being built
+		return
+	}
+	construction := state.Constructions[index]
+	state.Constructions = slices.Delete(state.Constructions, index, index+1)
+	ctx.Send(state.database, messages.DeleteConstructionMessage{
+		ConstructionId: construction.ConstructionId,
+	})
+
+	getBuildingPIDResponse, err := Request[messages.GetBuildingPIDResponseMessage](ctx, GetManagerPID(), messages.GetBuildingPIDMessage{
+		BuildingId: construction.BuildingId,
+	})
+	if err != nil {
+		log.Printf("Error completing construction %s: %s", construction.ConstructionId, err)
+	} else if getBuildingPIDResponse.PID == nil {
+		log.Printf("Error completing construction %s: %s", construction.ConstructionId, &messages.BuildingNotFoundError{BuildingId: construction.BuildingId})
+	} else {
+		ctx.Send(getBuildingPIDResponse.PID, messages.CompleteBuildingLevelMessage{
+			Level: construction.Level,
+		})
+	}
+
+	if state.City.Owner != "" {
+		ws.Send(state.City.Owner, messages.WS_CONSTRUCTION_COMPLETE, &models.ConstructionOutput{
+			Construction: construction,
+			Eta:          construction.End,
+		})
+	}
+
+	state.updatePositions(ctx)
+	state.scheduleConstructions(ctx)
+	state.wsConstructions()
+}
+
+// starts waiting constructions while builder slots are free, a level only
+// starts once the previous level of the same building is finished
+func (state *CityActor) scheduleConstructions(ctx actor.Context) {
+	active := 0
+	for _, construction := range state.Constructions {
+		if construction.Started {
+			active++
+		}
+	}
+
+	for i := range state.Constructions {
+		if active >= constants.CONSTRUCTION_SLOTS {
+			return
+		}
+		construction := &state.Constructions[i]
+		if construction.Started || !state.isLevelReady(i, nil, time.Time{}) {
+			continue
+		}
+
+		construction.Started = true
+		construction.End = time.Now().Add(time.Duration(construction.Duration) * time.Second)
+		active++
+		ctx.Send(state.database, messages.UpdateConstructionMessage{
+			Construction: *construction,
+		})
+		state.scheduleCompletion(ctx, *construction)
+	}
+}
+
+func (state *CityActor) scheduleCompletion(ctx actor.Context, construction models.Construction) {
+	go func() {
+		time.Sleep(time.Until(construction.End))
+		ctx.Send(ctx.Self(), messages.CompleteConstructionMessage{
+			ConstructionId: construction.ConstructionId,
+		})
+	}()
+}
+
+// checks that the previous levels of the building queued before a construction
+// are done, either in the queue itself or by the given estimates
+func (state *CityActor) isLevelReady(index int, etas map[string]time.Time, now time.Time) bool {
+	buildingId := state.Constructions[index].BuildingId
+	for _, construction := range state.Constructions[:index] {
+		if construction.BuildingId != buildingId {
+			continue
+		}
+		eta, ok := etas[construction.ConstructionId]
+		if !ok || eta.After(now) {
+			return false
+		}
+	}
+	return true
+}
+
+// simulates the queue to estimate when each construction will be finished
+func (state *CityActor) getConstructionOutputs() []models.ConstructionOutput {
+	etas := make(map[string]time.Time)
+	var running []time.Time
+	for _, construction := range state.Constructions {
+		if construction.Started {
+			etas[construction.ConstructionId] = construction.End
+			running = append(running, construction.End)
+		}
+	}
+
+	now := time.Now()
+	for len(etas) < len(state.Constructions) {
+		running = slices.DeleteFunc(running, func(end time.Time) bool {
+			return !end.After(now)
+		})
+		for i, construction := range state.Constructions {
+			if len(running) >= constants.CONSTRUCTION_SLOTS {
+				break
+			}
+			if _, ok := etas[construction.ConstructionId]; ok || !state.isLevelReady(i, etas, now) {
+				continue
+			}
+			end := now.Add(time.Duration(construction.Duration) * time.Second)
+			etas[construction.ConstructionId] = end
+			running = append(running, end)
+		}
+		if len(running) == 0 {
+			break
+		}
+		now = slices.MinFunc(running, func(a, b time.Time) int {
+			return a.Compare(b)
+		})
+	}
+
+	outputs := make([]models.ConstructionOutput, len(state.Constructions))
+	for i, construction := range state.Constructions {
+		outputs[i] = models.ConstructionOutput{
+			Construction: construction,
+			Eta:          etas[construction.ConstructionId],
+		}
+	}
+	return outputs
+}
+
+func (state *CityActor) findConstruction(constructionId string) int {
+	return slices.IndexFunc(state.Constructions, func(construction models.Construction) bool {
+		return construction.ConstructionId == constructionId
+	})
+}
+
+// persists the positions of constructions that moved in the queue
+func (state *CityActor) updatePositions(ctx actor.Context) {
+	for i := range state.Constructions {
+		construction := &state.Constructions[i]
+		if construction.Position == i {
+			continue
+		}
+		construction.Position = i
+		ctx.Send(state.database, messages.UpdateConstructionMessage{
+			Construction: *construction,
+		})
+	}
+}
+
+func (state *CityActor) wsConstructions() {
+	if state.City.Owner == "" {
+		return
+	}
+	ws.Send(state.City.Owner, messages.WS_CONSTRUCTIONS, state.getConstructionOutputs())
+}
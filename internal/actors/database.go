@@ -20,6 +20,8 @@ type DatabaseActor struct {
 	cityBuffer []models.City
 	armyBuffer []models.Army
 
+	ledgerBuffer []models.LedgerEntry
+
 	ticker       *time.Ticker
 	stopTickerCh chan struct{}
 }
@@ -45,12 +47,19 @@ func (state *DatabaseActor) Receive(ctx actor.Context) {
 		if result.Error != nil {
 			log.Printf("Error deleting user in db: %s", result.Error)
 		}
+	case messages.CreateLedgerEntryMessage:
+		state.ledgerBuffer = append(state.ledgerBuffer, msg.Entry)
 
 	case messages.CreateMapTileMessage:
 		result := state.db.Create(&msg.Tile)
 		if result.Error != nil {
 			log.Printf("Error creating map tile in db: %s", result.Error)
 		}
+	case messages.UpdateMapTileMessage:
+		result := state.db.Save(&msg.Tile)
+		if result.Error != nil {
+			log.Printf("Error updating map tile in db: %s", result.Error)
+		}
 
 	case messages.CreateCityMessage:
 		result := state.db.Create(&msg.City)
@@ -81,6 +90,43 @@ func (state *DatabaseActor) Receive(ctx actor.Context) {
 			log.Printf("Error deleting building in db: %s", result.Error)
 		}
 
+	case messages.CreateConstructionMessage:
+		result := state.db.Create(&msg.Construction)
+		if result.Error != nil {
+			log.Printf("Error creating construction in db: %s", result.Error)
+		}
+	case messages.UpdateConstructionMessage:
+		result := state.db.Save(&msg.Construction)
+		if result.Error != nil {
+			log.Printf("Error updating construction in db: %s", result.Error)
+		}
+	case messages.DeleteConstructionMessage:
+		result := state.db.Where("construction_id = ?", msg.ConstructionId).Delete(&models.Construction{})
+		if result.Error != nil {
+			log.Printf("Error deleting construction in db: %s", result.Error)
+		}
+
+	case messages.CreateOrderMessage:
+		result := state.db.Create(&msg.Order)
+		if result.Error != nil {
+			log.Printf("Error creating order in db: %s", result.Error)
+		}
+	case messages.UpdateOrderMessage:
+		result := state.db.Save(&msg.Order)
+		if result.Error != nil {
+			log.Printf("Error updating order in db: %s", result.Error)
+		}
+	case messages.DeleteOrderMessage:
+		result := state.db.Where("order_id = ?", msg.OrderId).Delete(&models.MarketOrder{})
+		if result.Error != nil {
+			log.Printf("Error deleting order in db: %s", result.Error)
+		}
+	case messages.CreateTradeMessage:
+		result := state.db.Create(&msg.Trade)
+		if result.Error != nil {
+			log.Printf("Error creating trade in db: %s", result.Error)
+		}
+
 	case messages.CreateArmyMessage:
 		result := state.db.Create(&msg.Army)
 		if result.Error != nil {
@@ -102,8 +148,13 @@ func (state *DatabaseActor) Receive(ctx actor.Context) {
 		if result.Error != nil {
 			log.Printf("Error creating training in db: %s", result.Error)
 		}
+	case messages.UpdateTrainingMessage:
+		result := state.db.Save(&msg.Training)
+		if result.Error != nil {
+			log.Printf("Error updating training in db: %s", result.Error)
+		}
 	case messages.DeleteTrainingMessage:
-		result := state.db.Where("barracks_id = ?", msg.BarracksId).Delete(&models.Training{})
+		result := state.db.Where("training_id = ?", msg.TrainingId).Delete(&models.Training{})
 		if result.Error != nil {
 			log.Printf("Error deleting training in db: %s", result.Error)
 		}
@@ -132,6 +183,19 @@ func (state *DatabaseActor) Receive(ctx actor.Context) {
 			}
 			state.cityBuffer = make([]models.City, 0)
 		}
+
+		ledgerBatchSize := 5000
+		if len(state.ledgerBuffer) > 0 {
+			// all or nothing, a failed flush keeps the entries for the next tick
+			err := state.db.Transaction(func(tx *gorm.DB) error {
+				return tx.CreateInBatches(state.ledgerBuffer, ledgerBatchSize).Error
+			})
+			if err != nil {
+				log.Printf("Error creating ledger entries: %s", err)
+			} else {
+				state.ledgerBuffer = make([]models.LedgerEntry, 0)
+			}
+		}
 	}
 }
 
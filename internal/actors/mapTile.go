@@ -1,13 +1,19 @@
 package actors
 
 import (
+	"cityio/internal/combat"
+	"cityio/internal/constants"
 	"cityio/internal/messages"
 	"cityio/internal/models"
+	"cityio/internal/pathfinding"
+	"cityio/internal/ws"
 
 	"log"
+	"slices"
 	"sync"
 
 	"github.com/asynkron/protoactor-go/actor"
+	"github.com/google/uuid"
 )
 
 type army struct {
@@ -22,6 +28,7 @@ type MapTileActor struct {
 	CityPID     *actor.PID
 	BuildingPID *actor.PID
 	Armies      map[string][]*army
+	Caravans    map[string][]*army // kept apart so they never merge with or fight armies
 
 	cityOnce sync.Once
 }
@@ -32,6 +39,11 @@ func (state *MapTileActor) Receive(ctx actor.Context) {
 	case messages.CreateMapTileMessage:
 		state.Tile = msg.Tile
 		state.Armies = make(map[string][]*army)
+		state.Caravans = make(map[string][]*army)
+		if state.Tile.Terrain == "" {
+			state.Tile.Terrain = constants.TERRAIN_PLAINS
+		}
+		pathfinding.SetTerrain(state.Tile.X, state.Tile.Y, state.Tile.Terrain)
 		if !msg.Restore {
 			ctx.Send(state.database, messages.CreateMapTileMessage{
 				Tile: state.Tile,
@@ -42,18 +54,51 @@ func (state *MapTileActor) Receive(ctx actor.Context) {
 		})
 
 	case messages.AddCityToTileMessage:
-		state.Tile.CityId = msg.CityId
+		// cities are always built on plains, so every tile of them can be reached
+		if state.Tile.CityId != msg.CityId || state.Tile.Terrain != constants.TERRAIN_PLAINS {
+			state.Tile.CityId = msg.CityId
+			state.Tile.Terrain = constants.TERRAIN_PLAINS
+			pathfinding.SetTerrain(state.Tile.X, state.Tile.Y, state.Tile.Terrain)
+			ctx.Send(state.database, messages.UpdateMapTileMessage{
+				Tile: state.Tile,
+			})
+		}
 		ctx.Respond(messages.AddCityToTileResponseMessage{
 			Error: nil,
 		})
 
 	case messages.AddBuildingToTileMessage:
+		if state.Tile.BuildingId != "" && state.Tile.BuildingId != msg.BuildingId {
+			ctx.Respond(messages.AddBuildingToTileResponseMessage{
+				Error: &messages.TileOccupiedError{X: state.Tile.X, Y: state.Tile.Y, BuildingId: state.Tile.BuildingId},
+			})
+			return
+		}
 		state.Tile.BuildingId = msg.BuildingId
 		ctx.Respond(messages.AddBuildingToTileResponseMessage{
 			Error: nil,
 		})
 
+	case messages.RemoveBuildingFromTileMessage:
+		if state.Tile.BuildingId == msg.BuildingId {
+			state.Tile.BuildingId = ""
+		}
+		ctx.Respond(messages.RemoveBuildingFromTileResponseMessage{
+			Error: nil,
+		})
+
 	case messages.AddTileArmyMessage:
+		if msg.Army.Kind == constants.ARMY_KIND_CARAVAN {
+			state.Caravans[msg.Army.Owner] = append(state.Caravans[msg.Army.Owner], &army{
+				ArmyPID: msg.ArmyPID,
+				Army:    msg.Army,
+			})
+			state.robCaravans(ctx)
+			state.deliverCaravans(ctx)
+			state.publishArmies(constants.MAP_EVENT_ARMY_ENTERED, msg.Army.ArmyId)
+			return
+		}
+
 		// no armies from player on this tile
 		if _, ok := state.Armies[msg.Army.Owner]; !ok {
 			state.Armies[msg.Army.Owner] = append(make([]*army, 0), &army{
@@ -61,69 +106,94 @@ func (state *MapTileActor) Receive(ctx actor.Context) {
 				Army:    msg.Army,
 			})
 		} else {
-			log.Printf("Merging idle armies at %d, %d", state.Tile.X, state.Tile.Y)
 			state.Armies[msg.Army.Owner] = append(state.Armies[msg.Army.Owner], &army{
 				ArmyPID: msg.ArmyPID,
 				Army:    msg.Army,
 			})
-			mergeArmies := make([]*army, 0)
-			newArmies := make([]*army, 0)
-			for i := 0; i < len(state.Armies[msg.Army.Owner]); i++ {
-				if !state.Armies[msg.Army.Owner][i].Army.MarchActive {
-					mergeArmies = append(mergeArmies, state.Armies[msg.Army.Owner][i])
-				} else {
-					newArmies = append(newArmies, state.Armies[msg.Army.Owner][i])
-				}
-			}
-			// merge together all armies that are not marching anywhere
-			if len(mergeArmies) > 1 {
-				mergedArmy := &army{
-					ArmyPID: mergeArmies[0].ArmyPID,
-					Army:    mergeArmies[0].Army,
-				}
-				for i := 1; i < len(mergeArmies); i++ {
-					mergedArmy.Army.Size += mergeArmies[i].Army.Size
-					deleteArmyResponse, err := Request[messages.DeleteArmyResponseMessage](ctx, mergeArmies[i].ArmyPID, messages.DeleteArmyMessage{
-						ArmyId: mergeArmies[i].Army.ArmyId,
-					})
-					if err != nil {
-						log.Printf("Error merging army: %s", err)
-						return
-					}
-					if deleteArmyResponse.Error != nil {
-						log.Printf("Error merging army: %s", deleteArmyResponse.Error)
-						return
-					}
-				}
-				updateArmyMessage, err := Request[messages.UpdateArmyResponseMessage](ctx, mergedArmy.ArmyPID, messages.UpdateArmyMessage{
-					Army: mergedArmy.Army,
-				})
+			if !msg.NoMerge {
+				err := state.mergeIdleArmies(ctx, msg.Army.Owner)
 				if err != nil {
 					log.Printf("Error merging army: %s", err)
-					return
-				}
-				if updateArmyMessage.Error != nil {
-					log.Printf("Error merging army: %s", updateArmyMessage.Error)
-					return
 				}
-				newArmies = append([]*army{mergedArmy}, newArmies...)
-				state.Armies[msg.Army.Owner] = newArmies
 			}
 		}
+		state.resolveBattles(ctx, msg.Army.Owner)
+		state.robCaravans(ctx)
+		state.publishArmies(constants.MAP_EVENT_ARMY_ENTERED, msg.Army.ArmyId)
+
+		// an army that stopped inside a city may lay siege to it
+		if !msg.Army.MarchActive && state.Tile.CityId != "" {
+			state.startSiege(ctx, msg.Army.Owner)
+		}
+
+	case messages.UpdateTileArmyMessage:
+		armies := state.Armies
+		if msg.Army.Kind == constants.ARMY_KIND_CARAVAN {
+			armies = state.Caravans
+		}
+		for _, army := range armies[msg.Army.Owner] {
+			if army.Army.ArmyId == msg.Army.ArmyId {
+				army.Army = msg.Army
+				break
+			}
+		}
+		state.publishArmies(constants.MAP_EVENT_ARMY_UPDATED, msg.Army.ArmyId)
 
 	case messages.RemoveTileArmyMessage:
-		if len(state.Armies[msg.Owner]) == 1 {
-			delete(state.Armies, msg.Owner)
-		} else {
-			newArmies := make([]*army, 0)
-			for _, army := range state.Armies[msg.Owner] {
-				if army.Army.ArmyId != msg.ArmyId {
-					newArmies = append(newArmies, army)
-				}
+		removeArmy(state.Armies, msg.Owner, msg.ArmyId)
+		removeArmy(state.Caravans, msg.Owner, msg.ArmyId)
+		state.publishArmies(constants.MAP_EVENT_ARMY_LEFT, msg.ArmyId)
+
+	case messages.SplitTileArmyMessage:
+		armyId, err := state.splitArmy(ctx, msg.Owner, msg.ArmyId, msg.Troops)
+		ctx.Respond(messages.SplitTileArmyResponseMessage{
+			ArmyId: armyId,
+			Error:  err,
+		})
+
+	case messages.MergeTileArmiesMessage:
+		targets := make([]*army, 0)
+		for _, armyId := range msg.ArmyIds {
+			target, err := state.getIdleArmy(msg.Owner, armyId)
+			if err != nil {
+				ctx.Respond(messages.MergeTileArmiesResponseMessage{
+					Error: err,
+				})
+				return
+			}
+			if !slices.Contains(targets, target) {
+				targets = append(targets, target)
 			}
-			state.Armies[msg.Owner] = newArmies
+		}
+		if len(targets) < 2 {
+			ctx.Respond(messages.MergeTileArmiesResponseMessage{
+				Error: &messages.TooFewArmiesError{Count: len(targets)},
+			})
+			return
 		}
 
+		merged, err := state.mergeArmies(ctx, msg.Owner, targets)
+		if err != nil {
+			ctx.Respond(messages.MergeTileArmiesResponseMessage{
+				Error: err,
+			})
+			return
+		}
+		state.publishArmies(constants.MAP_EVENT_ARMY_UPDATED, merged.Army.ArmyId)
+		ctx.Respond(messages.MergeTileArmiesResponseMessage{
+			ArmyId: merged.Army.ArmyId,
+			Error:  nil,
+		})
+
+	case messages.TransferTileArmyMessage:
+		err := state.transferTroops(ctx, msg.Owner, msg.ArmyId, msg.Recipient, msg.Troops)
+		if err == nil {
+			state.publishArmies(constants.MAP_EVENT_ARMY_UPDATED, msg.ArmyId)
+		}
+		ctx.Respond(messages.TransferTileArmyResponseMessage{
+			Error: err,
+		})
+
 	case messages.GetMapTileMessage:
 		var city *models.City = nil
 		cityPID, err := state.getCityPID()
@@ -168,12 +238,13 @@ func (state *MapTileActor) Receive(ctx actor.Context) {
 			Tile:     state.Tile,
 			City:     city,
 			Building: building,
-			Armies:   state.getTileArmies(),
+			Armies:   state.getTileArmies(state.Armies),
+			Caravans: state.getTileArmies(state.Caravans),
 		})
 
 	case messages.GetMapTileArmiesMessage:
 		// returns names of owners and their armies
-		armies := state.getTileArmies()
+		armies := state.getTileArmies(state.Armies)
 		ctx.Respond(messages.GetMapTileArmiesResponseMessage{
 			Armies: armies,
 		})
@@ -192,11 +263,449 @@ func (state *MapTileActor) Receive(ctx actor.Context) {
 	}
 }
 
-func (state *MapTileActor) getTileArmies() map[string][]*models.Army {
+// merges together all armies of an owner on this tile that are not marching anywhere,
+// unless the owner has opted out of it
+func (state *MapTileActor) mergeIdleArmies(ctx actor.Context, owner string) error {
+	idleArmies := make([]*army, 0)
+	for _, army := range state.Armies[owner] {
+		if !army.Army.MarchActive {
+			idleArmies = append(idleArmies, army)
+		}
+	}
+	if len(idleArmies) <= 1 {
+		return nil
+	}
+
+	user, err := state.getUser(ctx, owner)
+	if err != nil {
+		return err
+	}
+	if user.DisableAutoMerge {
+		return nil
+	}
+
+	_, err = state.mergeArmies(ctx, owner, idleArmies)
+	return err
+}
+
+// merges the given armies of an owner into the first of them, destroying the others
+func (state *MapTileActor) mergeArmies(ctx actor.Context, owner string, targets []*army) (*army, error) {
+	log.Printf("Merging %d armies at %d, %d", len(targets), state.Tile.X, state.Tile.Y)
+	merged := targets[0]
+	troops := merged.Army.Troops
+	for _, target := range targets[1:] {
+		troops = troops.Add(target.Army.Troops)
+	}
+
+	updateArmyTroopsResponse, err := Request[messages.UpdateArmyTroopsResponseMessage](ctx, merged.ArmyPID, messages.UpdateArmyTroopsMessage{
+		Troops: troops,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if updateArmyTroopsResponse.Error != nil {
+		return nil, updateArmyTroopsResponse.Error
+	}
+	merged.Army.Troops = troops
+	merged.Army.Size = troops.Total()
+
+	newArmies := make([]*army, 0)
+	for _, army := range state.Armies[owner] {
+		if army == merged || !slices.Contains(targets, army) {
+			newArmies = append(newArmies, army)
+			continue
+		}
+		err := destroyArmy(ctx, army.ArmyPID, army.Army)
+		if err != nil {
+			log.Printf("Error destroying merged army %s: %s", army.Army.ArmyId, err)
+		}
+	}
+	state.Armies[owner] = newArmies
+	return merged, nil
+}
+
+// carves troops off an army into a new army on this tile, returning the id of the new army
+func (state *MapTileActor) splitArmy(ctx actor.Context, owner string, armyId string, troops models.Troops) (string, error) {
+	source, err := state.getIdleArmy(owner, armyId)
+	if err != nil {
+		return "", err
+	}
+	remaining, ok := source.Army.Troops.Subtract(troops)
+	if !ok || troops.Total() <= 0 || remaining.Total() <= 0 {
+		return "", &messages.InsufficientTroopsError{ArmyId: armyId}
+	}
+
+	updateArmyTroopsResponse, err := Request[messages.UpdateArmyTroopsResponseMessage](ctx, source.ArmyPID, messages.UpdateArmyTroopsMessage{
+		Troops: remaining,
+	})
+	if err != nil {
+		return "", err
+	}
+	if updateArmyTroopsResponse.Error != nil {
+		return "", updateArmyTroopsResponse.Error
+	}
+	source.Army.Troops = remaining
+	source.Army.Size = remaining.Total()
+
+	// the new army joins this tile on its own once created
+	newArmyId := uuid.New().String()
+	_, err = spawnArmy(ctx, models.Army{
+		ArmyId: newArmyId,
+		TileX:  state.Tile.X,
+		TileY:  state.Tile.Y,
+		Owner:  owner,
+		Troops: troops,
+	}, true)
+	if err != nil {
+		// give the troops back to the army they were taken from
+		source.Army.Troops = source.Army.Troops.Add(troops)
+		source.Army.Size = source.Army.Troops.Total()
+		ctx.Send(source.ArmyPID, messages.UpdateArmyTroopsMessage{
+			Troops: source.Army.Troops,
+		})
+		return "", err
+	}
+	log.Printf("Split army %s off army %s at %d, %d", newArmyId, armyId, state.Tile.X, state.Tile.Y)
+	return newArmyId, nil
+}
+
+// hands troops of an army over to an idle army of an ally on this tile
+func (state *MapTileActor) transferTroops(ctx actor.Context, owner string, armyId string, recipient string, troops models.Troops) error {
+	source, err := state.getIdleArmy(owner, armyId)
+	if err != nil {
+		return err
+	}
+
+	user, err := state.getUser(ctx, owner)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(user.Allies, recipient) {
+		return &messages.NotAlliedError{UserId: owner, AllyId: recipient}
+	}
+
+	var target *army = nil
+	for _, army := range state.Armies[recipient] {
+		if !army.Army.MarchActive {
+			target = army
+			break
+		}
+	}
+	if target == nil {
+		return &messages.NoArmyOnTileError{UserId: recipient, X: state.Tile.X, Y: state.Tile.Y}
+	}
+
+	remaining, ok := source.Army.Troops.Subtract(troops)
+	if !ok || troops.Total() <= 0 {
+		return &messages.InsufficientTroopsError{ArmyId: armyId}
+	}
+
+	received := target.Army.Troops.Add(troops)
+	updateArmyTroopsResponse, err := Request[messages.UpdateArmyTroopsResponseMessage](ctx, target.ArmyPID, messages.UpdateArmyTroopsMessage{
+		Troops: received,
+	})
+	if err != nil {
+		return err
+	}
+	if updateArmyTroopsResponse.Error != nil {
+		return updateArmyTroopsResponse.Error
+	}
+	target.Army.Troops = received
+	target.Army.Size = received.Total()
+
+	// an army that handed over all of its troops is disbanded
+	if remaining.Total() <= 0 {
+		err = destroyArmy(ctx, source.ArmyPID, source.Army)
+		if err != nil {
+			return err
+		}
+		newArmies := make([]*army, 0)
+		for _, army := range state.Armies[owner] {
+			if army != source {
+				newArmies = append(newArmies, army)
+			}
+		}
+		if len(newArmies) == 0 {
+			delete(state.Armies, owner)
+		} else {
+			state.Armies[owner] = newArmies
+		}
+	} else {
+		updateArmyTroopsResponse, err = Request[messages.UpdateArmyTroopsResponseMessage](ctx, source.ArmyPID, messages.UpdateArmyTroopsMessage{
+			Troops: remaining,
+		})
+		if err != nil {
+			return err
+		}
+		if updateArmyTroopsResponse.Error != nil {
+			return updateArmyTroopsResponse.Error
+		}
+		source.Army.Troops = remaining
+		source.Army.Size = remaining.Total()
+	}
+
+	log.Printf("Transferred %d troops from %s to %s at %d, %d", troops.Total(), owner, recipient, state.Tile.X, state.Tile.Y)
+	return nil
+}
+
+// finds an army of an owner on this tile that is not marching anywhere
+func (state *MapTileActor) getIdleArmy(owner string, armyId string) (*army, error) {
+	for _, army := range state.Armies[owner] {
+		if army.Army.ArmyId != armyId {
+			continue
+		}
+		if army.Army.MarchActive {
+			return nil, &messages.ArmyAlreadyMarchingError{ArmyId: armyId}
+		}
+		return army, nil
+	}
+	return nil, &messages.ArmyNotFoundError{ArmyId: armyId}
+}
+
+// fights every owner on this tile that is not allied with the given owner
+func (state *MapTileActor) resolveBattles(ctx actor.Context, attacker string) {
+	if _, ok := state.Armies[attacker]; !ok {
+		return
+	}
+	if len(state.Armies) == 1 {
+		return
+	}
+
+	attackerUser, err := state.getUser(ctx, attacker)
+	if err != nil {
+		log.Printf("Error resolving battles: %s", err)
+		return
+	}
+
+	for defender := range state.Armies {
+		if defender == attacker || slices.Contains(attackerUser.Allies, defender) {
+			continue
+		}
+		state.battle(ctx, attackerUser, defender)
+
+		// attacker was wiped out
+		if _, ok := state.Armies[attacker]; !ok {
+			return
+		}
+	}
+}
+
+func (state *MapTileActor) battle(ctx actor.Context, attackerUser *models.User, defender string) {
+	attackers := state.Armies[attackerUser.UserId]
+	defenders := state.Armies[defender]
+
+	attackerTroops := make(models.Troops)
+	for _, army := range attackers {
+		attackerTroops = attackerTroops.Add(army.Army.Troops)
+	}
+	defenderTroops := make(models.Troops)
+	for _, army := range defenders {
+		defenderTroops = defenderTroops.Add(army.Army.Troops)
+	}
+	attackerTotal := attackerTroops.Total()
+	defenderTotal := defenderTroops.Total()
+
+	attackerShare, defenderShare := combat.Resolve(combat.Attack(attackerTroops), combat.Defense(defenderTroops))
+	attackerSurvivors := state.applyLosses(ctx, attackerUser.UserId, attackerShare)
+	defenderSurvivors := state.applyLosses(ctx, defender, defenderShare)
+	log.Printf("Battle at (%d, %d): %d troops of %s against %d troops of %s, %d and %d survived",
+		state.Tile.X, state.Tile.Y, attackerTotal, attackerUser.UserId, defenderTotal, defender, attackerSurvivors, defenderSurvivors)
+
+	defenderName := defender
+	defenderUser, err := state.getUser(ctx, defender)
+	if err != nil {
+		log.Printf("Error getting defender: %s", err)
+	} else {
+		defenderName = defenderUser.Username
+	}
+	battle := &models.BattleOutput{
+		X:              state.Tile.X,
+		Y:              state.Tile.Y,
+		Attacker:       attackerUser.Username,
+		Defender:       defenderName,
+		AttackerLosses: attackerTotal - attackerSurvivors,
+		DefenderLosses: defenderTotal - defenderSurvivors,
+	}
+	ws.Send(attackerUser.UserId, messages.WS_BATTLE, battle)
+	ws.Send(defender, messages.WS_BATTLE, battle)
+}
+
+// shrinks the armies of an owner to the share of troops that survived a battle, destroying
+// any army with no troops left. Returns the number of troops the owner has left.
+func (state *MapTileActor) applyLosses(ctx actor.Context, owner string, share float64) int64 {
+	var survivors int64 = 0
+	newArmies := make([]*army, 0)
+	for _, army := range state.Armies[owner] {
+		troops := combat.Survivors(army.Army.Troops, share)
+		if troops.Total() <= 0 {
+			err := destroyArmy(ctx, army.ArmyPID, army.Army)
+			if err != nil {
+				log.Printf("Error destroying army %s: %s", army.Army.ArmyId, err)
+			}
+			continue
+		}
+
+		if troops.Total() != army.Army.Size {
+			army.Army.Troops = troops
+			army.Army.Size = troops.Total()
+			response, err := Request[messages.UpdateArmyTroopsResponseMessage](ctx, army.ArmyPID, messages.UpdateArmyTroopsMessage{
+				Troops: troops,
+			})
+			if err != nil {
+				log.Printf("Error updating army troops: %s", err)
+			} else if response.Error != nil {
+				log.Printf("Error updating army troops: %s", response.Error)
+			}
+		}
+		survivors += army.Army.Size
+		newArmies = append(newArmies, army)
+	}
+
+	if len(newArmies) == 0 {
+		delete(state.Armies, owner)
+	} else {
+		state.Armies[owner] = newArmies
+	}
+	return survivors
+}
+
+// hostile armies on this tile seize the cargo of every caravan that meets them
+func (state *MapTileActor) robCaravans(ctx actor.Context) {
+	if len(state.Caravans) == 0 || len(state.Armies) == 0 {
+		return
+	}
+	for owner, caravans := range state.Caravans {
+		robber, err := state.getRobber(ctx, owner)
+		if err != nil {
+			log.Printf("Error robbing caravans: %s", err)
+			continue
+		}
+		if robber == "" {
+			continue
+		}
+		for _, caravan := range caravans {
+			ctx.Send(caravan.ArmyPID, messages.UnloadCaravanMessage{
+				Recipient: robber,
+				Robbed:    true,
+			})
+		}
+		delete(state.Caravans, owner)
+	}
+}
+
+// finds the owner of the strongest armies on this tile that is hostile to the owner of a caravan
+func (state *MapTileActor) getRobber(ctx actor.Context, owner string) (string, error) {
+	user, err := state.getUser(ctx, owner)
+	if err != nil {
+		return "", err
+	}
+
+	robber := ""
+	var strongest int64 = 0
+	for other, armies := range state.Armies {
+		if other == owner || slices.Contains(user.Allies, other) {
+			continue
+		}
+		var troops int64 = 0
+		for _, army := range armies {
+			troops += army.Army.Size
+		}
+		if troops > strongest {
+			robber = other
+			strongest = troops
+		}
+	}
+	return robber, nil
+}
+
+// unloads the caravans that came to a halt in the city they were sent to
+func (state *MapTileActor) deliverCaravans(ctx actor.Context) {
+	if state.Tile.CityId == "" {
+		return
+	}
+	for owner, caravans := range state.Caravans {
+		remaining := make([]*army, 0)
+		for _, caravan := range caravans {
+			if caravan.Army.MarchActive || caravan.Army.DestinationId != state.Tile.CityId {
+				remaining = append(remaining, caravan)
+				continue
+			}
+			ctx.Send(caravan.ArmyPID, messages.UnloadCaravanMessage{
+				Recipient: caravan.Army.Recipient,
+				Robbed:    false,
+			})
+		}
+		if len(remaining) == 0 {
+			delete(state.Caravans, owner)
+		} else {
+			state.Caravans[owner] = remaining
+		}
+	}
+}
+
+func (state *MapTileActor) startSiege(ctx actor.Context, owner string) {
+	if _, ok := state.Armies[owner]; !ok {
+		// army was destroyed in battle
+		return
+	}
+	cityPID, err := state.getCityPID()
+	if err != nil || cityPID == nil {
+		log.Printf("Error starting siege: city %s not found", state.Tile.CityId)
+		return
+	}
+
+	for _, army := range state.Armies[owner] {
+		if !army.Army.MarchActive {
+			ctx.Send(cityPID, messages.StartSiegeMessage{
+				X:       state.Tile.X,
+				Y:       state.Tile.Y,
+				ArmyPID: army.ArmyPID,
+				Army:    army.Army,
+			})
+			return
+		}
+	}
+}
+
+func (state *MapTileActor) getUser(ctx actor.Context, userId string) (*models.User, error) {
+	getUserPIDResponse, err := Request[messages.GetUserPIDResponseMessage](ctx, GetManagerPID(), messages.GetUserPIDMessage{
+		UserId: userId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if getUserPIDResponse.PID == nil {
+		return nil, &messages.UserNotFoundError{UserId: userId}
+	}
+
+	getUserResponse, err := Request[messages.GetUserResponseMessage](ctx, getUserPIDResponse.PID, messages.GetUserMessage{})
+	if err != nil {
+		return nil, err
+	}
+	return &getUserResponse.User, nil
+}
+
+// pushes the armies now on this tile to the players watching it
+func (state *MapTileActor) publishArmies(event string, armyId string) {
+	area := models.Viewport{X: state.Tile.X, Y: state.Tile.Y, Width: 1, Height: 1}
+	if !ws.IsWatched(area) {
+		return
+	}
+	ws.Publish(area, messages.WS_MAP_DELTA, models.MapTileDeltaOutput{
+		X:        state.Tile.X,
+		Y:        state.Tile.Y,
+		Event:    event,
+		ArmyId:   armyId,
+		Armies:   state.getTileArmies(state.Armies),
+		Caravans: state.getTileArmies(state.Caravans),
+	})
+}
+
+func (state *MapTileActor) getTileArmies(tileArmies map[string][]*army) map[string][]*models.Army {
 	// TODO: add better error handling
 	ownerNames := make(map[string]string)
 	armies := make(map[string][]*models.Army)
-	for owner, _armies := range state.Armies {
+	for owner, _armies := range tileArmies {
 		newArmies := make([]*models.Army, 0)
 		for _, army := range _armies {
 			newArmies = append(newArmies, &army.Army)
@@ -258,3 +767,21 @@ func (state *MapTileActor) getBuildingPID() (*actor.PID, error) {
 	}
 	return getBuildingPIDResponse.PID, nil
 }
+
+// drops an army from the armies of its owner on a tile
+func removeArmy(armies map[string][]*army, owner string, armyId string) {
+	if _, ok := armies[owner]; !ok {
+		return
+	}
+	newArmies := make([]*army, 0)
+	for _, army := range armies[owner] {
+		if army.Army.ArmyId != armyId {
+			newArmies = append(newArmies, army)
+		}
+	}
+	if len(newArmies) == 0 {
+		delete(armies, owner)
+	} else {
+		armies[owner] = newArmies
+	}
+}
@@ -0,0 +1,288 @@
+package actors
+
+import (
+	"cityio/internal/constants"
+	"cityio/internal/messages"
+	"cityio/internal/models"
+	"cityio/internal/ws"
+
+	"log"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/google/uuid"
+)
+
+// MarketActor keeps the order book where players trade food for gold. Orders hold
+// what they offer in escrow, so settling a trade only ever credits both players.
+type MarketActor struct {
+	BaseActor
+	Orders []models.MarketOrder // open orders in the order they were placed
+}
+
+func (state *MarketActor) Receive(ctx actor.Context) {
+	switch msg := ctx.Message().(type) {
+
+	case messages.RestoreOrdersMessage:
+		state.Orders = msg.Orders
+		slices.SortStableFunc(state.Orders, func(a, b models.MarketOrder) int {
+			return a.CreatedAt.Compare(b.CreatedAt)
+		})
+		ctx.Respond(messages.RestoreOrdersResponseMessage{
+			Error: nil,
+		})
+
+	case messages.PlaceOrderMessage:
+		order, trades, err := state.placeOrder(ctx, msg.Order)
+		ctx.Respond(messages.PlaceOrderResponseMessage{
+			Order:  order,
+			Trades: trades,
+			Error:  err,
+		})
+
+	case messages.CancelOrderMessage:
+		ctx.Respond(messages.CancelOrderResponseMessage{
+			Error: state.cancelOrder(ctx, msg.OrderId, msg.UserId),
+		})
+
+	case messages.RetryCreditMessage:
+		state.credit(ctx, msg.UserId, msg.Credit, msg.Attempt)
+
+	case messages.GetOrdersMessage:
+		ctx.Respond(messages.GetOrdersResponseMessage{
+			Orders: slices.Clone(state.Orders),
+		})
+	}
+}
+
+// takes the offered resources into escrow, matches the order against the book
+// and keeps whatever is left open
+func (state *MarketActor) placeOrder(ctx actor.Context, order models.MarketOrder) (models.MarketOrder, []models.Trade, error) {
+	if order.Side != constants.ORDER_SIDE_BUY && order.Side != constants.ORDER_SIDE_SELL {
+		return models.MarketOrder{}, nil, &messages.InvalidOrderError{Reason: "side must be buy or sell"}
+	}
+	if order.Amount <= 0 {
+		return models.MarketOrder{}, nil, &messages.InvalidOrderError{Reason: "amount must be positive"}
+	}
+	if order.Price <= 0 || math.IsInf(order.Price, 0) || math.IsNaN(order.Price) {
+		return models.MarketOrder{}, nil, &messages.InvalidOrderError{Reason: "price must be positive"}
+	}
+	open := 0
+	for _, other := range state.Orders {
+		if other.UserId == order.UserId {
+			open++
+		}
+	}
+	if open >= constants.MARKET_ORDER_LIMIT {
+		return models.MarketOrder{}, nil, &messages.OrderLimitReachedError{UserId: order.UserId, Limit: constants.MARKET_ORDER_LIMIT}
+	}
+
+	userPID := state.getUserPID(ctx, order.UserId)
+	if userPID == nil {
+		return models.MarketOrder{}, nil, &messages.UserNotFoundError{UserId: order.UserId}
+	}
+	order.OrderId = uuid.New().String()
+	spend := messages.SpendResourcesMessage{
+		Reason: constants.LEDGER_REASON_MARKET_ESCROW,
+		Source: order.OrderId,
+	}
+	if order.Side == constants.ORDER_SIDE_BUY {
+		order.Escrow = int64(math.Ceil(float64(order.Amount) * order.Price))
+		spend.Gold = order.Escrow
+	} else {
+		order.Escrow = order.Amount
+		spend.Food = order.Escrow
+	}
+	spendResponse, err := Request[messages.SpendResourcesResponseMessage](ctx, userPID, spend)
+	if err != nil {
+		return models.MarketOrder{}, nil, err
+	}
+	if spendResponse.Error != nil {
+		return models.MarketOrder{}, nil, spendResponse.Error
+	}
+
+	order.CreatedAt = time.Now()
+	trades := state.match(ctx, &order)
+
+	if order.Amount > 0 {
+		state.Orders = append(state.Orders, order)
+		ctx.Send(state.database, messages.CreateOrderMessage{
+			Order: order,
+		})
+	} else {
+		state.closeOrder(ctx, order)
+	}
+	ws.Send(order.UserId, messages.WS_ORDER, &order)
+	return order, trades, nil
+}
+
+// fills an incoming order against the best priced resting orders, trades happen
+// at the price of the resting order
+func (state *MarketActor) match(ctx actor.Context, order *models.MarketOrder) []models.Trade {
+	var trades []models.Trade
+	for order.Amount > 0 {
+		index := state.getBestMatch(*order)
+		if index == -1 {
+			break
+		}
+		resting := &state.Orders[index]
+
+		buy, sell := order, resting
+		if order.Side == constants.ORDER_SIDE_SELL {
+			buy, sell = resting, order
+		}
+		food := min(order.Amount, resting.Amount)
+		// rounding down keeps the gold paid within what the buyer put in escrow
+		gold := int64(math.Floor(float64(food) * resting.Price))
+		buy.Amount -= food
+		buy.Escrow -= gold
+		sell.Amount -= food
+		sell.Escrow -= food
+
+		trade := models.Trade{
+			TradeId:     uuid.New().String(),
+			BuyOrderId:  buy.OrderId,
+			SellOrderId: sell.OrderId,
+			Buyer:       buy.UserId,
+			Seller:      sell.UserId,
+			Price:       resting.Price,
+			Food:        food,
+			Gold:        gold,
+			CreatedAt:   time.Now(),
+		}
+		state.settle(ctx, trade)
+		trades = append(trades, trade)
+
+		filled := *resting
+		if filled.Amount == 0 {
+			state.Orders = slices.Delete(state.Orders, index, index+1)
+			ctx.Send(state.database, messages.DeleteOrderMessage{
+				OrderId: filled.OrderId,
+			})
+			state.closeOrder(ctx, filled)
+		} else {
+			ctx.Send(state.database, messages.UpdateOrderMessage{
+				Order: filled,
+			})
+		}
+		ws.Send(filled.UserId, messages.WS_ORDER, &filled)
+	}
+	return trades
+}
+
+// finds the resting order with the best price that crosses the given order,
+// the earliest one wins between equal prices
+func (state *MarketActor) getBestMatch(order models.MarketOrder) int {
+	best := -1
+	for i, resting := range state.Orders {
+		if resting.Side == order.Side || resting.UserId == order.UserId {
+			continue
+		}
+		if order.Side == constants.ORDER_SIDE_BUY {
+			if resting.Price > order.Price || (best != -1 && resting.Price >= state.Orders[best].Price) {
+				continue
+			}
+		} else {
+			if resting.Price < order.Price || (best != -1 && resting.Price <= state.Orders[best].Price) {
+				continue
+			}
+		}
+		best = i
+	}
+	return best
+}
+
+// hands the food to the buyer and the gold to the seller, both already left
+// their accounts when the orders were placed
+func (state *MarketActor) settle(ctx actor.Context, trade models.Trade) {
+	state.credit(ctx, trade.Buyer, messages.CreditResourcesMessage{
+		Food:   trade.Food,
+		Reason: constants.LEDGER_REASON_MARKET_TRADE,
+		Source: trade.TradeId,
+	}, 0)
+	state.credit(ctx, trade.Seller, messages.CreditResourcesMessage{
+		Gold:   trade.Gold,
+		Reason: constants.LEDGER_REASON_MARKET_TRADE,
+		Source: trade.TradeId,
+	}, 0)
+
+	ctx.Send(state.database, messages.CreateTradeMessage{
+		Trade: trade,
+	})
+	ws.Send(trade.Buyer, messages.WS_TRADE, &trade)
+	ws.Send(trade.Seller, messages.WS_TRADE, &trade)
+}
+
+func (state *MarketActor) cancelOrder(ctx actor.Context, orderId string, userId string) error {
+	index := slices.IndexFunc(state.Orders, func(order models.MarketOrder) bool {
+		return order.OrderId == orderId
+	})
+	if index == -1 {
+		return &messages.OrderNotFoundError{OrderId: orderId}
+	}
+	order := state.Orders[index]
+	if order.UserId != userId {
+		return &messages.OrderNotOwnedError{OrderId: orderId, UserId: userId}
+	}
+
+	state.Orders = slices.Delete(state.Orders, index, index+1)
+	ctx.Send(state.database, messages.DeleteOrderMessage{
+		OrderId: orderId,
+	})
+	state.closeOrder(ctx, order)
+	order.Amount = 0
+	ws.Send(order.UserId, messages.WS_ORDER, &order)
+	return nil
+}
+
+// returns what is left in escrow to the owner of an order leaving the book
+func (state *MarketActor) closeOrder(ctx actor.Context, order models.MarketOrder) {
+	if order.Escrow <= 0 {
+		return
+	}
+	refund := messages.CreditResourcesMessage{
+		Reason: constants.LEDGER_REASON_MARKET_REFUND,
+		Source: order.OrderId,
+	}
+	if order.Side == constants.ORDER_SIDE_BUY {
+		refund.Gold = order.Escrow
+	} else {
+		refund.Food = order.Escrow
+	}
+	state.credit(ctx, order.UserId, refund, 0)
+}
+
+// pays out a trade or refund, trying again later if the player cannot be reached
+func (state *MarketActor) credit(ctx actor.Context, userId string, credit messages.CreditResourcesMessage, attempt int) {
+	err := creditUser(ctx, userId, credit)
+	if err == nil {
+		return
+	}
+	if attempt >= constants.CREDIT_RETRY_LIMIT {
+		log.Printf("Error paying %d gold and %d food to %s for %s, giving up: %s", credit.Gold, credit.Food, userId, credit.Source, err)
+		return
+	}
+	log.Printf("Error paying %s for %s, retrying: %s", userId, credit.Source, err)
+
+	self := ctx.Self()
+	time.AfterFunc(constants.CREDIT_RETRY_DELAY*time.Second, func() {
+		system.Root.Send(self, messages.RetryCreditMessage{
+			UserId:  userId,
+			Credit:  credit,
+			Attempt: attempt + 1,
+		})
+	})
+}
+
+func (state *MarketActor) getUserPID(ctx actor.Context, userId string) *actor.PID {
+	getUserPIDResponse, err := Request[messages.GetUserPIDResponseMessage](ctx, state.manager, messages.GetUserPIDMessage{
+		UserId: userId,
+	})
+	if err != nil {
+		log.Printf("Error getting user pid: %s", err)
+		return nil
+	}
+	return getUserPIDResponse.PID
+}
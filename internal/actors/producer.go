@@ -0,0 +1,135 @@
+package actors
+
+import (
+	"cityio/internal/constants"
+	"cityio/internal/messages"
+
+	"log"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+// ProducerActor runs any building whose behaviour is fully described by the
+// balance file: it raises the population cap of its city as levels are finished
+// and periodically hands its production to the owner.
+type ProducerActor struct {
+	BuildingActor
+
+	ticker       *time.Ticker
+	stopTickerCh chan struct{}
+}
+
+func (state *ProducerActor) Receive(ctx actor.Context) {
+	switch msg := ctx.Message().(type) {
+
+	case messages.CreateBuildingMessage:
+		state.Building = msg.Building
+		// buildings saved before their housing was tracked are assumed to house what the balance says
+		backfilled := false
+		if state.Building.Housing == 0 && state.Building.Level > 0 {
+			state.Building.Housing = constants.GetBuildingPopulation(state.Building.Type, state.Building.Level)
+			backfilled = state.Building.Housing != 0
+		}
+		if msg.Restore && backfilled {
+			ctx.Send(state.database, messages.UpdateBuildingMessage{
+				Building: state.Building,
+			})
+		}
+		if !msg.Restore {
+			ctx.Send(state.database, messages.CreateBuildingMessage{
+				Building: state.Building,
+			})
+			state.publish(&state.Building)
+		}
+		ctx.Respond(messages.CreateBuildingResponseMessage{
+			Error: nil,
+		})
+		state.startPeriodicOperation(ctx)
+
+	case messages.CompleteBuildingLevelMessage:
+		state.completeLevel(ctx, msg.Level)
+		err := state.updateHousing(ctx)
+		if err != nil {
+			log.Printf("Error completing level %d of building %s: %s", msg.Level, state.Building.BuildingId, err)
+		}
+
+	case messages.PeriodicOperationMessage:
+		state.produce(ctx)
+
+	case messages.GetBuildingMessage:
+		ctx.Respond(messages.GetBuildingResponseMessage{
+			Building: state.Building,
+		})
+
+	case messages.DeleteBuildingMessage:
+		state.stopPeriodicOperation()
+		// the population housed by the building moves out with it
+		err := state.updatePopulationCap(ctx, -state.Building.Housing)
+		if err != nil {
+			log.Printf("Error deleting building %s: %s", state.Building.BuildingId, err)
+		}
+		state.deleteBuilding(ctx)
+	}
+}
+
+// moves the population cap of the city by the difference between what the building
+// houses at its level and what it added so far, so reloading the balance never makes it drift
+func (state *ProducerActor) updateHousing(ctx actor.Context) error {
+	housing := constants.GetBuildingPopulation(state.Building.Type, state.Building.Level)
+	err := state.updatePopulationCap(ctx, housing-state.Building.Housing)
+	if err != nil {
+		return err
+	}
+	if housing != state.Building.Housing {
+		state.Building.Housing = housing
+		ctx.Send(state.database, messages.UpdateBuildingMessage{
+			Building: state.Building,
+		})
+	}
+	return nil
+}
+
+func (state *ProducerActor) updatePopulationCap(ctx actor.Context, change float64) error {
+	if change == 0 {
+		return nil
+	}
+
+	response, err := Request[messages.UpdateCityPopulationCapResponseMessage](ctx, state.getCityPID(), messages.UpdateCityPopulationCapMessage{
+		Change: change,
+	})
+	if err != nil {
+		log.Printf("Error updating city population cap: %s", err)
+		return err
+	}
+	if response.Error != nil {
+		log.Printf("Error updating city population cap: %s", response.Error)
+		return response.Error
+	}
+	return nil
+}
+
+func (state *ProducerActor) startPeriodicOperation(ctx actor.Context) {
+	state.ticker = time.NewTicker(constants.BUILDING_PRODUCTION_FREQUENCY * time.Second)
+	state.stopTickerCh = make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-state.ticker.C:
+				ctx.Send(ctx.Self(), messages.PeriodicOperationMessage{})
+			case <-state.stopTickerCh:
+				state.ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (state *ProducerActor) stopPeriodicOperation() {
+	select {
+	case <-state.stopTickerCh:
+	default:
+		close(state.stopTickerCh)
+	}
+}
@@ -0,0 +1,44 @@
+package actors
+
+import (
+	"cityio/internal/constants"
+	"cityio/internal/messages"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+// actor constructors keyed by building type
+var buildingRegistry = make(map[string]func() BaseActorInterface)
+
+func init() {
+	newProducer := func() BaseActorInterface { return &ProducerActor{} }
+	newStorage := func() BaseActorInterface { return &StorageActor{} }
+
+	RegisterBuilding(constants.BUILDING_TYPE_CITY_CENTER, newProducer)
+	RegisterBuilding(constants.BUILDING_TYPE_TOWN_CENTER, newProducer)
+	RegisterBuilding(constants.BUILDING_TYPE_HOUSE, newProducer)
+	RegisterBuilding(constants.BUILDING_TYPE_FARM, newProducer)
+	RegisterBuilding(constants.BUILDING_TYPE_MINE, newProducer)
+	// markets only need to exist for their city to trade
+	RegisterBuilding(constants.BUILDING_TYPE_MARKET, newProducer)
+	RegisterBuilding(constants.BUILDING_TYPE_BARRACKS, func() BaseActorInterface { return &BarracksActor{} })
+	RegisterBuilding(constants.BUILDING_TYPE_WAREHOUSE, newStorage)
+	RegisterBuilding(constants.BUILDING_TYPE_GRANARY, newStorage)
+}
+
+// RegisterBuilding sets the actor spawned for a building type. Buildings that only
+// produce resources or house population can use ProducerActor and be described in
+// the balance file. Registration is not synchronized and belongs in an init function.
+func RegisterBuilding(buildingType string, newActor func() BaseActorInterface) {
+	buildingRegistry[buildingType] = newActor
+}
+
+func SpawnBuilding(buildingType string) (*actor.PID, error) {
+	newActor, ok := buildingRegistry[buildingType]
+	if !ok {
+		return nil, &messages.BuildingTypeNotFoundError{
+			BuildingType: buildingType,
+		}
+	}
+	return Spawn(newActor())
+}
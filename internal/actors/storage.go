@@ -0,0 +1,126 @@
+package actors
+
+import (
+	"cityio/internal/constants"
+	"cityio/internal/messages"
+
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+// StorageActor raises the gold and food caps of the player owning its city,
+// used for warehouses and granaries
+type StorageActor struct {
+	BuildingActor
+
+	// owner the capacity is currently registered with
+	registeredPID *actor.PID
+	registered    constants.BuildingStorage
+
+	ticker       *time.Ticker
+	stopTickerCh chan struct{}
+}
+
+func (state *StorageActor) Receive(ctx actor.Context) {
+	switch msg := ctx.Message().(type) {
+
+	case messages.CreateBuildingMessage:
+		state.Building = msg.Building
+		if !msg.Restore {
+			ctx.Send(state.database, messages.CreateBuildingMessage{
+				Building: state.Building,
+			})
+			state.publish(&state.Building)
+		}
+		ctx.Respond(messages.CreateBuildingResponseMessage{
+			Error: nil,
+		})
+		state.register(ctx)
+		state.startPeriodicOperation(ctx)
+
+	case messages.CompleteBuildingLevelMessage:
+		state.completeLevel(ctx, msg.Level)
+		state.register(ctx)
+
+	case messages.PeriodicOperationMessage:
+		// picks up cities changing hands
+		state.register(ctx)
+
+	case messages.GetBuildingMessage:
+		ctx.Respond(messages.GetBuildingResponseMessage{
+			Building: state.Building,
+		})
+
+	case messages.DeleteBuildingMessage:
+		state.stopPeriodicOperation()
+		state.unregister(ctx)
+		state.deleteBuilding(ctx)
+	}
+}
+
+// registers the capacity of this building with the current owner of its city
+func (state *StorageActor) register(ctx actor.Context) {
+	userPID := state.getUserPID()
+	storage := state.getStorage()
+	if userPID == state.registeredPID && storage == state.registered {
+		return
+	}
+
+	state.unregister(ctx)
+	if userPID == nil {
+		// not owned by a player
+		return
+	}
+	ctx.Send(userPID, messages.SetUserStorageMessage{
+		BuildingId: state.Building.BuildingId,
+		Gold:       storage.Gold,
+		Food:       storage.Food,
+	})
+	state.registeredPID = userPID
+	state.registered = storage
+}
+
+func (state *StorageActor) unregister(ctx actor.Context) {
+	if state.registeredPID == nil {
+		return
+	}
+	ctx.Send(state.registeredPID, messages.RemoveUserStorageMessage{
+		BuildingId: state.Building.BuildingId,
+	})
+	state.registeredPID = nil
+	state.registered = constants.BuildingStorage{}
+}
+
+func (state *StorageActor) getStorage() constants.BuildingStorage {
+	// the first level is still being built
+	if state.Building.Level < 1 {
+		return constants.BuildingStorage{}
+	}
+	return constants.GetBuildingStorage(state.Building.Type, state.Building.Level)
+}
+
+func (state *StorageActor) startPeriodicOperation(ctx actor.Context) {
+	state.ticker = time.NewTicker(constants.BUILDING_PRODUCTION_FREQUENCY * time.Second)
+	state.stopTickerCh = make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-state.ticker.C:
+				ctx.Send(ctx.Self(), messages.PeriodicOperationMessage{})
+			case <-state.stopTickerCh:
+				state.ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (state *StorageActor) stopPeriodicOperation() {
+	select {
+	case <-state.stopTickerCh:
+	default:
+		close(state.stopTickerCh)
+	}
+}
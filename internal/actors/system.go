@@ -16,10 +16,12 @@ import (
 var system *actor.ActorSystem
 var managerPID *actor.PID
 var databasePID *actor.PID
+var marketPID *actor.PID
 
 var systemOnce sync.Once
 var managerPIDOnce sync.Once
 var databasePIDOnce sync.Once
+var marketPIDOnce sync.Once
 
 type BaseActorInterface interface {
 	Receive(ctx actor.Context)
@@ -75,6 +77,11 @@ func initDatabaseActor() {
 	system.Root.Send(databasePID, messages.InitDatabaseMessage{})
 }
 
+func initMarketActor() {
+	marketPID, _ = Spawn(&MarketActor{})
+	log.Printf("Spawned market actor with PID: %s", marketPID)
+}
+
 func GetSystem() *actor.ActorSystem {
 	systemOnce.Do(initSystem)
 	return system
@@ -90,6 +97,11 @@ func GetDatabasePID() *actor.PID {
 	return databasePID
 }
 
+func GetMarketPID() *actor.PID {
+	marketPIDOnce.Do(initMarketActor)
+	return marketPID
+}
+
 func Spawn[T BaseActorInterface](ac T) (*actor.PID, error) {
 	return SpawnBase(func() actor.Actor {
 		return ac
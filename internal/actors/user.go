@@ -10,12 +10,14 @@ import (
 	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
+	"github.com/google/uuid"
 )
 
 type UserActor struct {
 	BaseActor
 	User     models.User
 	ArmyPIDs map[string]*actor.PID
+	Storage  map[string]constants.BuildingStorage // capacity added by each storage building
 
 	ticker       *time.Ticker
 	stopTickerCh chan struct{}
@@ -27,6 +29,7 @@ func (state *UserActor) Receive(ctx actor.Context) {
 	case messages.RegisterUserMessage:
 		state.User = msg.User
 		state.ArmyPIDs = make(map[string]*actor.PID)
+		state.Storage = make(map[string]constants.BuildingStorage)
 		if !msg.Restore {
 			registerUserResponse, err := Request[messages.RegisterUserResponseMessage](ctx, state.database, messages.RegisterUserMessage{
 				User: state.User,
@@ -71,7 +74,18 @@ func (state *UserActor) Receive(ctx actor.Context) {
 		})
 
 	case messages.UpdateUserGoldMessage:
-		state.User.Gold += msg.Change
+		if state.User.Gold+msg.Change < 0 {
+			ctx.Respond(messages.UpdateUserGoldResponseMessage{
+				Error: &messages.InsufficientResourcesError{
+					UserId: state.User.UserId,
+					Gold:   -msg.Change,
+				},
+			})
+			return
+		}
+		gold := state.User.Gold
+		state.User.Gold = addCapped(state.User.Gold, msg.Change, state.getGoldCap())
+		state.record(ctx, constants.RESOURCE_GOLD, state.User.Gold-gold, msg.Reason, msg.Source)
 		state.ws()
 
 		ctx.Respond(messages.UpdateUserGoldResponseMessage{
@@ -79,23 +93,135 @@ func (state *UserActor) Receive(ctx actor.Context) {
 		})
 
 	case messages.UpdateUserFoodMessage:
-		state.User.Food += msg.Change
+		if state.User.Food+msg.Change < 0 {
+			ctx.Respond(messages.UpdateUserFoodResponseMessage{
+				Error: &messages.InsufficientResourcesError{
+					UserId: state.User.UserId,
+					Food:   -msg.Change,
+				},
+			})
+			return
+		}
+		food := state.User.Food
+		state.User.Food = addCapped(state.User.Food, msg.Change, state.getFoodCap())
+		state.record(ctx, constants.RESOURCE_FOOD, state.User.Food-food, msg.Reason, msg.Source)
 		state.ws()
 		ctx.Respond(messages.UpdateUserFoodResponseMessage{
 			Error: nil,
 		})
 
+	case messages.SpendResourcesMessage:
+		// spending a negative amount would be a gain that skips the storage cap
+		if msg.Gold < 0 || msg.Food < 0 {
+			ctx.Respond(messages.SpendResourcesResponseMessage{
+				Error: &messages.InvalidResourceAmountError{
+					UserId: state.User.UserId,
+					Gold:   msg.Gold,
+					Food:   msg.Food,
+				},
+			})
+			return
+		}
+		// gold and food are checked together so nothing is taken unless both can be paid
+		if state.User.Gold < msg.Gold || state.User.Food < msg.Food {
+			ctx.Respond(messages.SpendResourcesResponseMessage{
+				Error: &messages.InsufficientResourcesError{
+					UserId: state.User.UserId,
+					Gold:   msg.Gold,
+					Food:   msg.Food,
+				},
+			})
+			return
+		}
+		state.User.Gold -= msg.Gold
+		state.User.Food -= msg.Food
+		state.record(ctx, constants.RESOURCE_GOLD, -msg.Gold, msg.Reason, msg.Source)
+		state.record(ctx, constants.RESOURCE_FOOD, -msg.Food, msg.Reason, msg.Source)
+		state.ws()
+		ctx.Respond(messages.SpendResourcesResponseMessage{
+			Error: nil,
+		})
+
+	case messages.CreditResourcesMessage:
+		if msg.Gold < 0 || msg.Food < 0 {
+			ctx.Respond(messages.CreditResourcesResponseMessage{
+				Error: &messages.InvalidResourceAmountError{
+					UserId: state.User.UserId,
+					Gold:   msg.Gold,
+					Food:   msg.Food,
+				},
+			})
+			return
+		}
+		state.User.Gold += msg.Gold
+		state.User.Food += msg.Food
+		state.record(ctx, constants.RESOURCE_GOLD, msg.Gold, msg.Reason, msg.Source)
+		state.record(ctx, constants.RESOURCE_FOOD, msg.Food, msg.Reason, msg.Source)
+		state.ws()
+		ctx.Respond(messages.CreditResourcesResponseMessage{
+			Error: nil,
+		})
+
+	case messages.SetUserStorageMessage:
+		state.Storage[msg.BuildingId] = constants.BuildingStorage{
+			Gold: msg.Gold,
+			Food: msg.Food,
+		}
+		state.ws()
+		ctx.Respond(messages.SetUserStorageResponseMessage{
+			Error: nil,
+		})
+
+	case messages.RemoveUserStorageMessage:
+		delete(state.Storage, msg.BuildingId)
+		state.ws()
+		ctx.Respond(messages.RemoveUserStorageResponseMessage{
+			Error: nil,
+		})
+
+	case messages.ConsumeFoodMessage:
+		consumed := min(state.User.Food, max(msg.Amount, 0))
+		state.User.Food -= consumed
+		if consumed > 0 {
+			state.record(ctx, constants.RESOURCE_FOOD, -consumed, msg.Reason, msg.Source)
+			state.ws()
+		}
+		ctx.Respond(messages.ConsumeFoodResponseMessage{
+			Consumed: consumed,
+		})
+
+	case messages.UpdateUserSettingsMessage:
+		state.User.DisableAutoMerge = msg.DisableAutoMerge
+		ctx.Send(state.database, &messages.UpdateUserMessage{
+			User: state.User,
+		})
+		state.ws()
+		ctx.Respond(messages.UpdateUserSettingsResponseMessage{
+			Error: nil,
+		})
+
 	case messages.GetUserMessage:
 		ctx.Respond(messages.GetUserResponseMessage{
 			User: state.User,
 		})
 
+	case messages.GetUserAccountMessage:
+		ctx.Respond(messages.GetUserAccountResponseMessage{
+			Account: state.getAccount(),
+		})
+
 	case messages.AddUserArmyMessage:
 		state.ArmyPIDs[msg.ArmyId] = msg.ArmyPID
 		ctx.Respond(messages.AddUserArmyResponseMessage{
 			Error: nil,
 		})
 
+	case messages.RemoveUserArmyMessage:
+		delete(state.ArmyPIDs, msg.ArmyId)
+		ctx.Respond(messages.RemoveUserArmyResponseMessage{
+			Error: nil,
+		})
+
 	case messages.DeleteUserMessage:
 		ctx.Send(state.database, messages.DeleteUserMessage{
 			UserId: state.User.UserId,
@@ -112,6 +238,11 @@ func (state *UserActor) Receive(ctx actor.Context) {
 		ctx.Send(state.database, &messages.UpdateUserMessage{
 			User: state.User,
 		})
+
+		// armies collect their food from this actor, so they must not be waited on here
+		for _, armyPID := range state.ArmyPIDs {
+			ctx.Send(armyPID, messages.PayArmyUpkeepMessage{})
+		}
 	}
 }
 
@@ -137,11 +268,90 @@ func (state *UserActor) stopPeriodicOperation() {
 	state.ticker = nil
 }
 
+// writes a change that was applied to a resource to the ledger
+func (state *UserActor) record(ctx actor.Context, resource string, delta int64, reason string, source string) {
+	if delta == 0 {
+		return
+	}
+	balance := state.User.Gold
+	if resource == constants.RESOURCE_FOOD {
+		balance = state.User.Food
+	}
+	ctx.Send(state.database, messages.CreateLedgerEntryMessage{
+		Entry: models.LedgerEntry{
+			EntryId:   uuid.New().String(),
+			UserId:    state.User.UserId,
+			Resource:  resource,
+			Delta:     delta,
+			Balance:   balance,
+			Reason:    reason,
+			Source:    source,
+			CreatedAt: time.Now(),
+		},
+	})
+}
+
 func (state *UserActor) ws() {
-	ws.Send(state.User.UserId, messages.WS_USER, &models.UserAccountOutput{
+	account := state.getAccount()
+	ws.Send(state.User.UserId, messages.WS_USER, &account)
+}
+
+func (state *UserActor) getAccount() models.UserAccountOutput {
+	goldCap := state.getGoldCap()
+	foodCap := state.getFoodCap()
+	return models.UserAccountOutput{
 		Username: state.User.Username,
 		Gold:     state.User.Gold,
 		Food:     state.User.Food,
+		GoldCap:  goldCap,
+		FoodCap:  foodCap,
+		GoldFill: float64(state.User.Gold) / float64(goldCap),
+		FoodFill: float64(state.User.Food) / float64(foodCap),
 		Allies:   state.User.Allies,
+
+		DisableAutoMerge: state.User.DisableAutoMerge,
+	}
+}
+
+func (state *UserActor) getGoldCap() int64 {
+	var capacity int64 = constants.BASE_STORAGE_CAPACITY
+	for _, storage := range state.Storage {
+		capacity += storage.Gold
+	}
+	return capacity
+}
+
+func (state *UserActor) getFoodCap() int64 {
+	var capacity int64 = constants.BASE_STORAGE_CAPACITY
+	for _, storage := range state.Storage {
+		capacity += storage.Food
+	}
+	return capacity
+}
+
+// pays resources out to a player, see CreditResourcesMessage
+func creditUser(ctx actor.Context, userId string, credit messages.CreditResourcesMessage) error {
+	getUserPIDResponse, err := Request[messages.GetUserPIDResponseMessage](ctx, GetManagerPID(), messages.GetUserPIDMessage{
+		UserId: userId,
 	})
+	if err != nil {
+		return err
+	}
+	if getUserPIDResponse.PID == nil {
+		return &messages.UserNotFoundError{UserId: userId}
+	}
+	creditResponse, err := Request[messages.CreditResourcesResponseMessage](ctx, getUserPIDResponse.PID, credit)
+	if err != nil {
+		return err
+	}
+	return creditResponse.Error
+}
+
+// adds a change to a resource without letting gains push it past the cap. A resource
+// that is already over the cap, e.g. after a warehouse was demolished, is left as is
+func addCapped(current int64, change int64, capacity int64) int64 {
+	if change <= 0 {
+		return current + change
+	}
+	return min(current+change, max(current, capacity))
 }
@@ -0,0 +1,30 @@
+package actors
+
+import "testing"
+
+func TestAddCapped(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  int64
+		change   int64
+		capacity int64
+		expected int64
+	}{
+		{name: "gain below the cap", current: 100, change: 50, capacity: 1000, expected: 150},
+		{name: "gain up to the cap", current: 900, change: 100, capacity: 1000, expected: 1000},
+		{name: "gain past the cap", current: 900, change: 500, capacity: 1000, expected: 1000},
+		{name: "gain while full", current: 1000, change: 10, capacity: 1000, expected: 1000},
+		{name: "gain while over the cap", current: 1500, change: 10, capacity: 1000, expected: 1500},
+		{name: "loss while over the cap", current: 1500, change: -200, capacity: 1000, expected: 1300},
+		{name: "loss below the cap", current: 500, change: -200, capacity: 1000, expected: 300},
+		{name: "no change", current: 500, change: 0, capacity: 1000, expected: 500},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if result := addCapped(test.current, test.change, test.capacity); result != test.expected {
+				t.Errorf("addCapped(%d, %d, %d) = %d, expected %d", test.current, test.change, test.capacity, result, test.expected)
+			}
+		})
+	}
+}
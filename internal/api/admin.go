@@ -0,0 +1,41 @@
+package api
+
+import (
+	"cityio/internal/constants"
+	"cityio/internal/models"
+
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+)
+
+// adminHandler only lets through requests carrying the ADMIN_TOKEN, admin routes
+// are disabled entirely when no token is configured
+func adminHandler(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		adminToken := os.Getenv("ADMIN_TOKEN")
+		token := request.Header.Get("Admin-Token")
+		if adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			response.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(response, request)
+	})
+}
+
+func ReloadBalance(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received POST /admin/balance/reload")
+
+	version, err := constants.ReloadBalance()
+	if err != nil {
+		log.Printf("Error reloading balance: %s", err)
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	log.Printf("Reloaded balance version %d", version)
+	json.NewEncoder(response).Encode(models.BalanceOutput{Version: version})
+}
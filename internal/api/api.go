@@ -12,8 +12,11 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"reflect"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -34,7 +37,28 @@ func DecodeBody[T any](request *http.Request) (T, error) {
 	return obj, nil
 }
 
+func DecodeSocketData[T any](msg *models.WebSocketRequest) (T, error) {
+	var obj T
+	dataBytes, err := json.Marshal(msg.Data)
+	if err != nil {
+		log.Printf("Error marshalling data: %s", err)
+		return obj, err
+	}
+
+	if err := json.Unmarshal(dataBytes, &obj); err != nil {
+		log.Printf("Error unmarshalling data: %s", err)
+		return obj, &messages.InvalidRequestError{Reason: err.Error()}
+	}
+
+	return obj, nil
+}
+
 func GetClaims(request *http.Request) models.UserClaims {
+	// authHandler stores the already validated claims
+	if claims, ok := request.Context().Value("claims").(models.UserClaims); ok {
+		return claims
+	}
+
 	ctxClaims := request.Context().Value("claims").(jwt.MapClaims)
 	var claims models.UserClaims
 
@@ -77,25 +101,44 @@ func Start() {
 	log.Fatal(server.ListenAndServe())
 }
 
-func ProcessSocketMessage(ctx context.Context, conn *websocket.Conn, messageType int, p []byte) error {
+// answers a single socket request with a frame carrying the id of the request, either the
+// result or an error frame. Only failing to queue the reply is returned as an error.
+func ProcessSocketMessage(ctx context.Context, session *ws.Session, messageType int, p []byte) error {
 	var message models.WebSocketRequest
 	if err := json.Unmarshal(p, &message); err != nil {
 		log.Printf("Error decoding WebSocket message: %s", err)
-		return err
+		return session.Write(socketError(nil, &messages.InvalidRequestError{Reason: err.Error()}))
 	}
 
+	var reply *models.WebSocketResponse
+	var err error
 	prefix := message.Req / 100
 	switch prefix {
 	case 10:
-		conn.WriteJSON(&models.WebSocketResponse{
-			Msg: messages.WS_PONG,
-		})
-		return nil
+		reply = &models.WebSocketResponse{Msg: messages.WS_PONG}
 	case 20:
-		return getMapTiles(ctx, &message)
+		reply, err = processMapRequest(ctx, &message)
+	case 22:
+		reply, err = processBuildingRequest(ctx, &message)
+	case 23:
+		reply, err = processArmyRequest(ctx, &message)
+	case 25:
+		reply, err = processMarketRequest(ctx, &message)
+	default:
+		err = &messages.UnknownRequestError{Req: message.Req}
 	}
 
-	return nil
+	if err != nil {
+		claims := ctx.Value("claims").(models.UserClaims)
+		log.Printf("Error processing request %d for %s: %s", message.Req, claims.Username, err)
+		return session.Write(socketError(message.Id, err))
+	}
+	// the handler already queued its reply
+	if reply == nil {
+		return nil
+	}
+	reply.Id = message.Id
+	return session.Write(reply)
 }
 
 func HandleWebSocket(response http.ResponseWriter, request *http.Request) {
@@ -114,6 +157,18 @@ func HandleWebSocket(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	// set by clients resuming after a disconnect
+	var lastSeq *uint64
+	if values.Has("lastSeq") {
+		seq, err := strconv.ParseUint(values.Get("lastSeq"), 10, 64)
+		if err != nil {
+			log.Printf("Error parsing last sequence: %s", err)
+			http.Error(response, "Invalid lastSeq", http.StatusBadRequest)
+			return
+		}
+		lastSeq = &seq
+	}
+
 	upgrader := websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool {
 			// check origin for security
@@ -129,9 +184,16 @@ func HandleWebSocket(response http.ResponseWriter, request *http.Request) {
 	}
 	defer conn.Close()
 
-	ws.AddConnection(claims.UserId, conn)
+	// only the session writes to the connection from here on
+	session, err := ws.AddConnection(claims.UserId, conn, lastSeq)
+	if err != nil {
+		log.Printf("Error resuming WebSocket session of %s: %s", claims.Username, err)
+		return
+	}
+	defer session.Close()
 	ctx := context.WithValue(request.Context(), "claims", claims)
-	log.Printf("WebSocket connection established with %s", claims.Username)
+	ctx = context.WithValue(ctx, "session", session)
+	log.Printf("WebSocket connection %s established with %s", session.SessionId, claims.Username)
 
 	user, err := services.GetUserAccount(claims.UserId)
 	if err != nil {
@@ -139,7 +201,7 @@ func HandleWebSocket(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	err = ws.Send(claims.UserId, messages.WS_USER, user)
+	err = session.Write(&models.WebSocketResponse{Msg: messages.WS_USER, Data: user})
 	if err != nil {
 		log.Printf("Error sending user: %s", err)
 		return
@@ -156,7 +218,7 @@ func HandleWebSocket(response http.ResponseWriter, request *http.Request) {
 			break
 		}
 
-		err = ProcessSocketMessage(ctx, conn, messageType, p)
+		err = ProcessSocketMessage(ctx, session, messageType, p)
 		if err != nil {
 			log.Printf("Error processing WebSocket message: %s", err)
 			break
@@ -164,6 +226,64 @@ func HandleWebSocket(response http.ResponseWriter, request *http.Request) {
 	}
 }
 
+// maps errors returned by the services to http status codes
+func errorStatus(err error) int {
+	switch err.(type) {
+	case *messages.UserNotFoundError, *messages.CityNotFoundError, *messages.BuildingNotFoundError,
+		*messages.ArmyNotFoundError, *messages.MapTileNotFoundError, *messages.ConstructionNotFoundError,
+		*messages.TrainingNotFoundError, *messages.OrderNotFoundError:
+		return http.StatusNotFound
+	case *messages.CityNotOwnedError, *messages.ArmyNotOwnedError, *messages.NotAlliedError, *messages.OrderNotOwnedError:
+		return http.StatusForbidden
+	case *messages.BuildingTypeNotFoundError, *messages.InvalidCoordinatesError, *messages.TileOccupiedError,
+		*messages.TileOutsideCityError, *messages.BuildingTypeNotAllowedError, *messages.BuildingLimitReachedError,
+		*messages.BuildingNotDemolishableError, *messages.MaxLevelReachedError, *messages.InsufficientResourcesError, *messages.InvalidResourceAmountError,
+		*messages.ArmyAlreadyMarchingError, *messages.ArmyNotMarchingError, *messages.NoPathError, *messages.InvalidTroopTypeError,
+		*messages.InsufficientTroopsError, *messages.TooFewArmiesError, *messages.NoArmyOnTileError,
+		*messages.InvalidTaxRateError, *messages.InvalidBalanceError, *messages.ConstructionInProgressError,
+		*messages.ConstructionQueueFullError, *messages.InvalidQueuePositionError, *messages.BuildingUnderConstructionError,
+		*messages.TrainingQueueFullError, *messages.InvalidTrainingSizeError, *messages.NotBarracksError, *messages.InvalidOrderError,
+		*messages.OrderLimitReachedError, *messages.MarketRequiredError, *messages.InvalidCaravanError,
+		*messages.InvalidRequestError, *messages.UnknownRequestError:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// wraps an error into a frame for the client, errors of the messages package are
+// identified by their type, e.g. ArmyNotFoundError becomes army_not_found
+func socketError(id json.RawMessage, err error) *models.WebSocketResponse {
+	return &models.WebSocketResponse{
+		Id:  id,
+		Msg: messages.WS_ERROR,
+		Data: &models.WebSocketError{
+			Code:    errorCode(err),
+			Status:  errorStatus(err),
+			Message: err.Error(),
+		},
+	}
+}
+
+func errorCode(err error) string {
+	errorType := reflect.TypeOf(err)
+	if errorType.Kind() == reflect.Pointer {
+		errorType = errorType.Elem()
+	}
+	if errorType.PkgPath() != reflect.TypeOf(messages.UnknownError{}).PkgPath() {
+		return "internal"
+	}
+
+	var code strings.Builder
+	for i, r := range strings.TrimSuffix(errorType.Name(), "Error") {
+		if i > 0 && unicode.IsUpper(r) {
+			code.WriteByte('_')
+		}
+		code.WriteRune(unicode.ToLower(r))
+	}
+	return code.String()
+}
+
 func recoverMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
@@ -228,4 +348,43 @@ func addRoutes(router *mux.Router) {
 	userRouter.HandleFunc("/login", Login).Methods("POST")
 	userRouter.HandleFunc("/{userId}", DeleteUser).Methods("DELETE")
 	userRouter.HandleFunc("/validate", authHandler(ValidateToken)).Methods("GET")
+	userRouter.HandleFunc("/settings", authHandler(UpdateUserSettings)).Methods("PUT")
+	userRouter.HandleFunc("/income", authHandler(GetIncome)).Methods("GET")
+
+	buildingRouter := router.PathPrefix("/buildings").Subrouter()
+
+	buildingRouter.HandleFunc("", authHandler(ConstructBuilding)).Methods("POST")
+	buildingRouter.HandleFunc("/{buildingId}/upgrade", authHandler(UpgradeBuilding)).Methods("POST")
+	buildingRouter.HandleFunc("/{buildingId}", authHandler(DemolishBuilding)).Methods("DELETE")
+	buildingRouter.HandleFunc("/{buildingId}/training", authHandler(TrainTroops)).Methods("POST")
+	buildingRouter.HandleFunc("/{buildingId}/training", authHandler(GetTrainings)).Methods("GET")
+	buildingRouter.HandleFunc("/{buildingId}/training/{trainingId}", authHandler(CancelTraining)).Methods("DELETE")
+
+	cityRouter := router.PathPrefix("/cities").Subrouter()
+
+	cityRouter.HandleFunc("/{cityId}/tax", authHandler(SetCityTaxRate)).Methods("PUT")
+	cityRouter.HandleFunc("/{cityId}/constructions", authHandler(GetConstructions)).Methods("GET")
+	cityRouter.HandleFunc("/{cityId}/constructions/{constructionId}", authHandler(ReorderConstruction)).Methods("PUT")
+	cityRouter.HandleFunc("/{cityId}/constructions/{constructionId}", authHandler(CancelConstruction)).Methods("DELETE")
+	cityRouter.HandleFunc("/{cityId}/caravans", authHandler(SendCaravan)).Methods("POST")
+
+	armyRouter := router.PathPrefix("/armies").Subrouter()
+
+	armyRouter.HandleFunc("/{armyId}/march", authHandler(MarchArmy)).Methods("POST")
+	armyRouter.HandleFunc("/{armyId}/march", authHandler(RedirectArmy)).Methods("PUT")
+	armyRouter.HandleFunc("/{armyId}/march", authHandler(CancelArmyMarch)).Methods("DELETE")
+	armyRouter.HandleFunc("/merge", authHandler(MergeArmies)).Methods("POST")
+	armyRouter.HandleFunc("/{armyId}/split", authHandler(SplitArmy)).Methods("POST")
+	armyRouter.HandleFunc("/{armyId}/transfer", authHandler(TransferTroops)).Methods("POST")
+
+	marketRouter := router.PathPrefix("/market").Subrouter()
+
+	marketRouter.HandleFunc("/orders", authHandler(GetOrders)).Methods("GET")
+	marketRouter.HandleFunc("/orders", authHandler(PlaceOrder)).Methods("POST")
+	marketRouter.HandleFunc("/orders/{orderId}", authHandler(CancelOrder)).Methods("DELETE")
+	marketRouter.HandleFunc("/trades", authHandler(GetTrades)).Methods("GET")
+
+	adminRouter := router.PathPrefix("/admin").Subrouter()
+
+	adminRouter.HandleFunc("/balance/reload", adminHandler(ReloadBalance)).Methods("POST")
 }
@@ -0,0 +1,378 @@
+package api
+
+import (
+	"cityio/internal/constants"
+	"cityio/internal/messages"
+	"cityio/internal/models"
+	"cityio/internal/services"
+	"cityio/internal/ws"
+
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func MarchArmy(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received POST /armies/march")
+
+	input, err := DecodeBody[models.ArmyMarchRequest](request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	input.ArmyId = mux.Vars(request)["armyId"]
+
+	march, err := marchArmy(GetClaims(request), input, false)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	json.NewEncoder(response).Encode(march)
+}
+
+func RedirectArmy(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received PUT /armies/march")
+
+	input, err := DecodeBody[models.ArmyMarchRequest](request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	input.ArmyId = mux.Vars(request)["armyId"]
+
+	march, err := marchArmy(GetClaims(request), input, true)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	json.NewEncoder(response).Encode(march)
+}
+
+func CancelArmyMarch(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received DELETE /armies/march")
+
+	march, err := cancelArmyMarch(GetClaims(request), mux.Vars(request)["armyId"])
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	json.NewEncoder(response).Encode(march)
+}
+
+func SplitArmy(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received POST /armies/split")
+
+	input, err := DecodeBody[models.ArmySplitRequest](request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	input.ArmyId = mux.Vars(request)["armyId"]
+
+	armies, err := splitArmy(GetClaims(request), input)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	json.NewEncoder(response).Encode(armies)
+}
+
+func MergeArmies(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received POST /armies/merge")
+
+	input, err := DecodeBody[models.ArmyMergeRequest](request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	army, err := mergeArmies(GetClaims(request), input)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	json.NewEncoder(response).Encode(army)
+}
+
+func TransferTroops(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received POST /armies/transfer")
+
+	input, err := DecodeBody[models.ArmyTransferRequest](request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	input.ArmyId = mux.Vars(request)["armyId"]
+
+	err = transferTroops(GetClaims(request), input)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+
+func SendCaravan(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received POST /cities/caravans")
+
+	input, err := DecodeBody[models.CaravanRequest](request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	input.CityId = mux.Vars(request)["cityId"]
+
+	caravan, err := sendCaravan(GetClaims(request), input)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	json.NewEncoder(response).Encode(caravan)
+}
+
+func processArmyRequest(ctx context.Context, msg *models.WebSocketRequest) (*models.WebSocketResponse, error) {
+	claims := ctx.Value("claims").(models.UserClaims)
+
+	var result interface{}
+	var err error
+	switch msg.Req {
+	case messages.WS_REQ_ARMY_MARCH, messages.WS_REQ_ARMY_REDIRECT, messages.WS_REQ_ARMY_CANCEL_MARCH:
+		input, decodeErr := DecodeSocketData[models.ArmyMarchRequest](msg)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		switch msg.Req {
+		case messages.WS_REQ_ARMY_MARCH:
+			result, err = marchArmy(claims, input, false)
+		case messages.WS_REQ_ARMY_REDIRECT:
+			result, err = marchArmy(claims, input, true)
+		case messages.WS_REQ_ARMY_CANCEL_MARCH:
+			result, err = cancelArmyMarch(claims, input.ArmyId)
+		}
+
+	case messages.WS_REQ_ARMY_SPLIT:
+		input, decodeErr := DecodeSocketData[models.ArmySplitRequest](msg)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		result, err = splitArmy(claims, input)
+
+	case messages.WS_REQ_ARMY_MERGE:
+		input, decodeErr := DecodeSocketData[models.ArmyMergeRequest](msg)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		result, err = mergeArmies(claims, input)
+
+	case messages.WS_REQ_ARMY_TRANSFER:
+		input, decodeErr := DecodeSocketData[models.ArmyTransferRequest](msg)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		err = transferTroops(claims, input)
+
+	case messages.WS_REQ_CARAVAN_SEND:
+		input, decodeErr := DecodeSocketData[models.CaravanRequest](msg)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		result, err = sendCaravan(claims, input)
+
+	default:
+		return nil, &messages.UnknownRequestError{Req: msg.Req}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return &models.WebSocketResponse{Msg: messages.WS_OK, Data: result}, nil
+}
+
+func marchArmy(claims models.UserClaims, input models.ArmyMarchRequest, redirect bool) (models.ArmyMarchOutput, error) {
+	if input.X < 0 || input.Y < 0 || input.X >= constants.MAP_SIZE || input.Y >= constants.MAP_SIZE {
+		return models.ArmyMarchOutput{}, &messages.InvalidCoordinatesError{X: input.X, Y: input.Y}
+	}
+	army, err := getOwnedTroops(claims, input.ArmyId)
+	if err != nil {
+		return models.ArmyMarchOutput{}, err
+	}
+
+	var arrival time.Time
+	if redirect {
+		arrival, err = services.RedirectArmy(army.ArmyId, input.X, input.Y)
+	} else {
+		arrival, err = services.MarchArmy(army.ArmyId, input.X, input.Y)
+	}
+	if err != nil {
+		return models.ArmyMarchOutput{}, err
+	}
+
+	march := models.ArmyMarchOutput{
+		ArmyId:      army.ArmyId,
+		TileX:       army.TileX,
+		TileY:       army.TileY,
+		ToX:         input.X,
+		ToY:         input.Y,
+		MarchActive: true,
+		Arrival:     arrival,
+	}
+	ws.Send(claims.UserId, messages.WS_ARMY, &march)
+	return march, nil
+}
+
+func cancelArmyMarch(claims models.UserClaims, armyId string) (models.ArmyMarchOutput, error) {
+	_, err := getOwnedTroops(claims, armyId)
+	if err != nil {
+		return models.ArmyMarchOutput{}, err
+	}
+
+	err = services.CancelArmyMarch(armyId)
+	if err != nil {
+		return models.ArmyMarchOutput{}, err
+	}
+
+	army, err := services.GetArmy(armyId)
+	if err != nil {
+		return models.ArmyMarchOutput{}, err
+	}
+	march := models.ArmyMarchOutput{
+		ArmyId:      army.ArmyId,
+		TileX:       army.TileX,
+		TileY:       army.TileY,
+		ToX:         army.TileX,
+		ToY:         army.TileY,
+		MarchActive: false,
+		Arrival:     time.Now(),
+	}
+	ws.Send(claims.UserId, messages.WS_ARMY, &march)
+	return march, nil
+}
+
+// returns the army that was split and the newly created army
+func splitArmy(claims models.UserClaims, input models.ArmySplitRequest) ([]models.Army, error) {
+	_, err := getOwnedTroops(claims, input.ArmyId)
+	if err != nil {
+		return nil, err
+	}
+
+	armyId, err := services.SplitArmy(input.ArmyId, input.Troops)
+	if err != nil {
+		return nil, err
+	}
+
+	armies := make([]models.Army, 0)
+	for _, id := range []string{input.ArmyId, armyId} {
+		army, err := services.GetArmy(id)
+		if err != nil {
+			return nil, err
+		}
+		ws.Send(claims.UserId, messages.WS_ARMY_TROOPS, &army)
+		armies = append(armies, army)
+	}
+	return armies, nil
+}
+
+func mergeArmies(claims models.UserClaims, input models.ArmyMergeRequest) (models.Army, error) {
+	for _, armyId := range input.ArmyIds {
+		_, err := getOwnedTroops(claims, armyId)
+		if err != nil {
+			return models.Army{}, err
+		}
+	}
+
+	armyId, err := services.MergeArmies(input.ArmyIds)
+	if err != nil {
+		return models.Army{}, err
+	}
+
+	army, err := services.GetArmy(armyId)
+	if err != nil {
+		return models.Army{}, err
+	}
+	ws.Send(claims.UserId, messages.WS_ARMY_TROOPS, &army)
+	return army, nil
+}
+
+func transferTroops(claims models.UserClaims, input models.ArmyTransferRequest) error {
+	_, err := getOwnedTroops(claims, input.ArmyId)
+	if err != nil {
+		return err
+	}
+
+	err = services.TransferTroops(input.ArmyId, input.Recipient, input.Troops)
+	if err != nil {
+		return err
+	}
+
+	// the army is gone if all of its troops were handed over
+	army, err := services.GetArmy(input.ArmyId)
+	if err == nil {
+		ws.Send(claims.UserId, messages.WS_ARMY_TROOPS, &army)
+	}
+	return nil
+}
+
+// caravans march like armies, so the player is told about them the same way
+func sendCaravan(claims models.UserClaims, input models.CaravanRequest) (models.CaravanOutput, error) {
+	err := checkCityOwner(claims, input.CityId)
+	if err != nil {
+		return models.CaravanOutput{}, err
+	}
+
+	caravan, err := services.SendCaravan(claims.UserId, input)
+	if err != nil {
+		return models.CaravanOutput{}, err
+	}
+
+	ws.Send(claims.UserId, messages.WS_ARMY, &models.ArmyMarchOutput{
+		ArmyId:      caravan.Caravan.ArmyId,
+		TileX:       caravan.Caravan.TileX,
+		TileY:       caravan.Caravan.TileY,
+		ToX:         caravan.Caravan.ToX,
+		ToY:         caravan.Caravan.ToY,
+		MarchActive: true,
+		Arrival:     caravan.Arrival,
+	})
+	return caravan, nil
+}
+
+// caravans march on their own once sent, so the army commands leave them alone
+func getOwnedTroops(claims models.UserClaims, armyId string) (models.Army, error) {
+	army, err := getOwnedArmy(claims, armyId)
+	if err != nil {
+		return models.Army{}, err
+	}
+	if army.Kind == constants.ARMY_KIND_CARAVAN {
+		return models.Army{}, &messages.InvalidCaravanError{Reason: "caravans cannot be commanded like armies"}
+	}
+	return army, nil
+}
+
+func getOwnedArmy(claims models.UserClaims, armyId string) (models.Army, error) {
+	army, err := services.GetArmy(armyId)
+	if err != nil {
+		return models.Army{}, err
+	}
+	if army.Owner != claims.UserId {
+		return models.Army{}, &messages.ArmyNotOwnedError{ArmyId: armyId, UserId: claims.UserId}
+	}
+	return army, nil
+}
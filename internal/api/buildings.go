@@ -0,0 +1,289 @@
+package api
+
+import (
+	"cityio/internal/messages"
+	"cityio/internal/models"
+	"cityio/internal/services"
+	"cityio/internal/ws"
+
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func ConstructBuilding(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received POST /buildings")
+
+	input, err := DecodeBody[models.BuildingInput](request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	building, err := constructBuilding(GetClaims(request), input)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	json.NewEncoder(response).Encode(building)
+}
+
+func UpgradeBuilding(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received POST /buildings/upgrade")
+
+	vars := mux.Vars(request)
+	construction, err := upgradeBuilding(GetClaims(request), vars["buildingId"])
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	json.NewEncoder(response).Encode(construction)
+}
+
+func DemolishBuilding(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received DELETE /buildings")
+
+	vars := mux.Vars(request)
+	err := demolishBuilding(GetClaims(request), vars["buildingId"])
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+
+func TrainTroops(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received POST /buildings/training")
+
+	input, err := DecodeBody[models.TrainingRequest](request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	input.BarracksId = mux.Vars(request)["buildingId"]
+	training, err := trainTroops(GetClaims(request), input)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	json.NewEncoder(response).Encode(training)
+}
+
+func GetTrainings(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received GET /buildings/training")
+
+	barracksId := mux.Vars(request)["buildingId"]
+	err := checkBuildingOwner(GetClaims(request), barracksId)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	trainings, err := services.GetTrainings(barracksId)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	json.NewEncoder(response).Encode(trainings)
+}
+
+func CancelTraining(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received DELETE /buildings/training")
+
+	vars := mux.Vars(request)
+	err := cancelTraining(GetClaims(request), vars["buildingId"], vars["trainingId"])
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+
+func processBuildingRequest(ctx context.Context, msg *models.WebSocketRequest) (*models.WebSocketResponse, error) {
+	claims := ctx.Value("claims").(models.UserClaims)
+
+	var result interface{}
+	var err error
+	switch msg.Req {
+	case messages.WS_REQ_BUILDING_CONSTRUCT:
+		input, decodeErr := DecodeSocketData[models.BuildingInput](msg)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		result, err = constructBuilding(claims, input)
+
+	case messages.WS_REQ_BUILDING_UPGRADE:
+		input, decodeErr := DecodeSocketData[models.BuildingRequest](msg)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		result, err = upgradeBuilding(claims, input.BuildingId)
+
+	case messages.WS_REQ_BUILDING_DEMOLISH:
+		input, decodeErr := DecodeSocketData[models.BuildingRequest](msg)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		err = demolishBuilding(claims, input.BuildingId)
+
+	case messages.WS_REQ_CONSTRUCTION_CANCEL:
+		input, decodeErr := DecodeSocketData[models.ConstructionRequest](msg)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		err = cancelConstruction(claims, input.CityId, input.ConstructionId)
+
+	case messages.WS_REQ_CONSTRUCTION_REORDER:
+		input, decodeErr := DecodeSocketData[models.ConstructionRequest](msg)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		err = reorderConstruction(claims, input)
+
+	case messages.WS_REQ_TRAINING_QUEUE:
+		input, decodeErr := DecodeSocketData[models.TrainingRequest](msg)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		result, err = trainTroops(claims, input)
+
+	case messages.WS_REQ_TRAINING_CANCEL:
+		input, decodeErr := DecodeSocketData[models.TrainingRequest](msg)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		err = cancelTraining(claims, input.BarracksId, input.TrainingId)
+
+	default:
+		return nil, &messages.UnknownRequestError{Req: msg.Req}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return &models.WebSocketResponse{Msg: messages.WS_OK, Data: result}, nil
+}
+
+func constructBuilding(claims models.UserClaims, input models.BuildingInput) (models.Building, error) {
+	err := checkCityOwner(claims, input.CityId)
+	if err != nil {
+		return models.Building{}, err
+	}
+
+	buildingId, err := services.ConstructBuilding(models.Building{
+		CityId: input.CityId,
+		Type:   input.Type,
+		Level:  1,
+		X:      input.X,
+		Y:      input.Y,
+	})
+	if err != nil {
+		return models.Building{}, err
+	}
+
+	building, err := services.GetBuilding(buildingId)
+	if err != nil {
+		return models.Building{}, err
+	}
+	ws.Send(claims.UserId, messages.WS_BUILDING, &building)
+	return building, nil
+}
+
+// the city pushes its updated construction queue once the level is queued
+func upgradeBuilding(claims models.UserClaims, buildingId string) (models.Construction, error) {
+	building, err := services.GetBuilding(buildingId)
+	if err != nil {
+		return models.Construction{}, err
+	}
+	err = checkCityOwner(claims, building.CityId)
+	if err != nil {
+		return models.Construction{}, err
+	}
+
+	return services.UpgradeBuilding(building)
+}
+
+func demolishBuilding(claims models.UserClaims, buildingId string) error {
+	building, err := services.GetBuilding(buildingId)
+	if err != nil {
+		return err
+	}
+	err = checkCityOwner(claims, building.CityId)
+	if err != nil {
+		return err
+	}
+
+	err = services.DemolishBuilding(buildingId)
+	if err != nil {
+		return err
+	}
+	ws.Send(claims.UserId, messages.WS_BUILDING_DEMOLISHED, &models.BuildingRequest{
+		BuildingId: buildingId,
+	})
+	return nil
+}
+
+// the barracks pushes its updated training queue itself
+func trainTroops(claims models.UserClaims, input models.TrainingRequest) (models.Training, error) {
+	err := checkBuildingOwner(claims, input.BarracksId)
+	if err != nil {
+		return models.Training{}, err
+	}
+	if input.DeployTo != "" {
+		err = checkCityOwner(claims, input.DeployTo)
+		if err != nil {
+			return models.Training{}, err
+		}
+	}
+
+	return services.TrainTroops(models.Training{
+		BarracksId: input.BarracksId,
+		TroopType:  input.TroopType,
+		Size:       input.Size,
+		DeployTo:   input.DeployTo,
+	})
+}
+
+func cancelTraining(claims models.UserClaims, barracksId string, trainingId string) error {
+	err := checkBuildingOwner(claims, barracksId)
+	if err != nil {
+		return err
+	}
+	return services.CancelTraining(barracksId, trainingId)
+}
+
+func checkBuildingOwner(claims models.UserClaims, buildingId string) error {
+	building, err := services.GetBuilding(buildingId)
+	if err != nil {
+		return err
+	}
+	return checkCityOwner(claims, building.CityId)
+}
+
+func checkCityOwner(claims models.UserClaims, cityId string) error {
+	city, err := services.GetCity(cityId)
+	if err != nil {
+		return err
+	}
+	if city.Owner != claims.UserId {
+		return &messages.CityNotOwnedError{CityId: cityId, UserId: claims.UserId}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"cityio/internal/messages"
+	"cityio/internal/models"
+	"cityio/internal/services"
+	"cityio/internal/ws"
+
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func SetCityTaxRate(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received PUT /cities/tax")
+
+	input, err := DecodeBody[models.CityTaxRequest](request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	claims := GetClaims(request)
+	cityId := mux.Vars(request)["cityId"]
+	err = checkCityOwner(claims, cityId)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	city, err := services.SetCityTaxRate(cityId, input.TaxRate)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	ws.Send(claims.UserId, messages.WS_CITY, &city)
+	json.NewEncoder(response).Encode(city)
+}
+
+func GetConstructions(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received GET /cities/constructions")
+
+	claims := GetClaims(request)
+	cityId := mux.Vars(request)["cityId"]
+	err := checkCityOwner(claims, cityId)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	constructions, err := services.GetConstructions(cityId)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	json.NewEncoder(response).Encode(constructions)
+}
+
+func ReorderConstruction(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received PUT /cities/constructions")
+
+	input, err := DecodeBody[models.ConstructionRequest](request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(request)
+	input.CityId = vars["cityId"]
+	input.ConstructionId = vars["constructionId"]
+	err = reorderConstruction(GetClaims(request), input)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+
+func CancelConstruction(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received DELETE /cities/constructions")
+
+	vars := mux.Vars(request)
+	err := cancelConstruction(GetClaims(request), vars["cityId"], vars["constructionId"])
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+
+// the city pushes its updated construction queue itself
+func reorderConstruction(claims models.UserClaims, input models.ConstructionRequest) error {
+	err := checkCityOwner(claims, input.CityId)
+	if err != nil {
+		return err
+	}
+	return services.ReorderConstruction(input.CityId, input.ConstructionId, input.Position)
+}
+
+func cancelConstruction(claims models.UserClaims, cityId string, constructionId string) error {
+	err := checkCityOwner(claims, cityId)
+	if err != nil {
+		return err
+	}
+	return services.CancelConstruction(cityId, constructionId)
+}
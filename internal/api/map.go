@@ -8,45 +8,85 @@ import (
 	"cityio/internal/ws"
 
 	"context"
-	"encoding/json"
-	"errors"
 	"log"
 )
 
-func getMapTiles(ctx context.Context, msg *models.WebSocketRequest) error {
+func processMapRequest(ctx context.Context, msg *models.WebSocketRequest) (*models.WebSocketResponse, error) {
+	switch msg.Req {
+	case messages.WS_REQ_MAP:
+		return getMapTiles(ctx, msg)
+	case messages.WS_REQ_MAP_SUBSCRIBE:
+		return subscribeMap(ctx, msg)
+	case messages.WS_REQ_MAP_UNSUBSCRIBE:
+		ws.Unsubscribe(ctx.Value("session").(*ws.Session))
+		return &models.WebSocketResponse{Msg: messages.WS_OK}, nil
+	default:
+		return nil, &messages.UnknownRequestError{Req: msg.Req}
+	}
+}
+
+// map tiles are only sent in reply to a request, so concurrent requests are told apart by their id
+func getMapTiles(ctx context.Context, msg *models.WebSocketRequest) (*models.WebSocketResponse, error) {
 	claims := ctx.Value("claims").(models.UserClaims)
 	log.Printf("Fetching map tiles for %s", claims.Username)
 
-	var data models.MapTileRequest
-	if dataMap, ok := msg.Data.(map[string]interface{}); ok {
-		dataBytes, err := json.Marshal(dataMap)
-		if err != nil {
-			log.Printf("Error marshalling data: %s", err)
-			return err
-		}
-
-		if err := json.Unmarshal(dataBytes, &data); err != nil {
-			log.Printf("Error unmarshalling data: %s", err)
-			return err
-		}
-	} else {
-		log.Println("Data is not in expected format.")
-		return errors.New("Data is not in expected format.")
+	data, err := DecodeSocketData[models.MapTileRequest](msg)
+	if err != nil {
+		return nil, err
 	}
 
 	x, y := data.X, data.Y
 	if x >= constants.MAP_SIZE || y >= constants.MAP_SIZE || x < 0 || y < 0 {
-		log.Printf("Invalid coordinates: x: %d, y: %d", x, y)
-		return nil
+		return nil, &messages.InvalidCoordinatesError{X: x, Y: y}
 	}
 	radius := data.Radius
 	if radius == 0 {
 		radius = 3
 	}
 
+	tiles := getTileArea(models.Viewport{
+		X:      x - radius,
+		Y:      y - radius,
+		Width:  2*radius + 1,
+		Height: 2*radius + 1,
+	})
+	return &models.WebSocketResponse{Msg: messages.WS_MAP, Data: &tiles}, nil
+}
+
+// the session gets the tiles of the viewport right away and then every change to them,
+// until the viewport is moved, the player unsubscribes or the session closes
+func subscribeMap(ctx context.Context, msg *models.WebSocketRequest) (*models.WebSocketResponse, error) {
+	claims := ctx.Value("claims").(models.UserClaims)
+
+	viewport, err := DecodeSocketData[models.Viewport](msg)
+	if err != nil {
+		return nil, err
+	}
+	if viewport.X >= constants.MAP_SIZE || viewport.Y >= constants.MAP_SIZE || viewport.X < 0 || viewport.Y < 0 {
+		return nil, &messages.InvalidCoordinatesError{X: viewport.X, Y: viewport.Y}
+	}
+	if viewport.Width <= 0 || viewport.Height <= 0 ||
+		viewport.Width > constants.VIEWPORT_MAX_SIZE || viewport.Height > constants.VIEWPORT_MAX_SIZE {
+		return nil, &messages.InvalidRequestError{Reason: "viewport is empty or too large"}
+	}
+	log.Printf("%s watches the map from x: %d, y: %d", claims.Username, viewport.X, viewport.Y)
+
+	// subscribe first so no change slips in between reading the tiles and watching them,
+	// the reply is queued by the subscription to come before any of those changes
+	session := ctx.Value("session").(*ws.Session)
+	ws.Subscribe(session, viewport)
+	tiles := getTileArea(viewport)
+	err = ws.FinishSubscribe(session, &models.WebSocketResponse{Id: msg.Id, Msg: messages.WS_MAP, Data: &tiles})
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func getTileArea(area models.Viewport) []models.MapTileOutput {
 	var tiles []models.MapTileOutput
-	for i := x - radius; i <= x+radius; i++ {
-		for j := y - radius; j <= y+radius; j++ {
+	for i := area.X; i < area.X+area.Width; i++ {
+		for j := area.Y; j < area.Y+area.Height; j++ {
 			if i < 0 || j < 0 || i >= constants.MAP_SIZE || j >= constants.MAP_SIZE {
 				continue
 			}
@@ -58,8 +98,5 @@ func getMapTiles(ctx context.Context, msg *models.WebSocketRequest) error {
 			tiles = append(tiles, tile)
 		}
 	}
-
-	ws.Send(claims.UserId, messages.WS_MAP, &tiles)
-
-	return nil
+	return tiles
 }
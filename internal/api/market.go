@@ -0,0 +1,121 @@
+package api
+
+import (
+	"cityio/internal/messages"
+	"cityio/internal/models"
+	"cityio/internal/services"
+
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+const tradeHistoryLimit = 50
+
+func GetOrders(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received GET /market/orders")
+
+	orders, err := services.GetOrders()
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	json.NewEncoder(response).Encode(orders)
+}
+
+func PlaceOrder(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received POST /market/orders")
+
+	input, err := DecodeBody[models.MarketOrderRequest](request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	output, err := placeOrder(GetClaims(request), input)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	json.NewEncoder(response).Encode(output)
+}
+
+func CancelOrder(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received DELETE /market/orders")
+
+	vars := mux.Vars(request)
+	err := services.CancelOrder(vars["orderId"], GetClaims(request).UserId)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+
+func GetTrades(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received GET /market/trades")
+
+	trades, err := services.GetTrades(GetClaims(request).UserId, tradeHistoryLimit)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	json.NewEncoder(response).Encode(trades)
+}
+
+func processMarketRequest(ctx context.Context, msg *models.WebSocketRequest) (*models.WebSocketResponse, error) {
+	claims := ctx.Value("claims").(models.UserClaims)
+
+	var result interface{}
+	var err error
+	switch msg.Req {
+	case messages.WS_REQ_MARKET_PLACE:
+		input, decodeErr := DecodeSocketData[models.MarketOrderRequest](msg)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		result, err = placeOrder(claims, input)
+
+	case messages.WS_REQ_MARKET_CANCEL:
+		input, decodeErr := DecodeSocketData[models.MarketOrderRequest](msg)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		err = services.CancelOrder(input.OrderId, claims.UserId)
+
+	default:
+		return nil, &messages.UnknownRequestError{Req: msg.Req}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return &models.WebSocketResponse{Msg: messages.WS_OK, Data: result}, nil
+}
+
+// the market pushes the order and any trades to the players involved
+func placeOrder(claims models.UserClaims, input models.MarketOrderRequest) (models.MarketOrderOutput, error) {
+	err := checkCityOwner(claims, input.CityId)
+	if err != nil {
+		return models.MarketOrderOutput{}, err
+	}
+
+	return services.PlaceOrder(models.MarketOrder{
+		UserId: claims.UserId,
+		CityId: input.CityId,
+		Side:   input.Side,
+		Price:  input.Price,
+		Amount: input.Amount,
+	})
+}
@@ -10,11 +10,18 @@ import (
 	"log"
 	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
+const (
+	incomeDefaultHours = 24
+	incomeMaxHours     = 7 * 24
+)
+
 func Register(response http.ResponseWriter, request *http.Request) {
 	log.Println("Received POST /users/register")
 
@@ -120,3 +127,48 @@ func DeleteUser(response http.ResponseWriter, request *http.Request) {
 
 	response.WriteHeader(http.StatusOK)
 }
+
+func UpdateUserSettings(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received PUT /users/settings")
+
+	settings, err := DecodeBody[models.UserSettingsRequest](request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	claims := GetClaims(request)
+	err = services.UpdateUserSettings(claims.UserId, settings)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+
+// breaks down the gold and food of the player over the last hours by reason
+func GetIncome(response http.ResponseWriter, request *http.Request) {
+	log.Println("Received GET /users/income")
+
+	hours := incomeDefaultHours
+	if value := request.URL.Query().Get("hours"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > incomeMaxHours {
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		hours = parsed
+	}
+
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+	income, err := services.GetIncome(GetClaims(request).UserId, since)
+	if err != nil {
+		response.WriteHeader(errorStatus(err))
+		json.NewEncoder(response).Encode(err.Error())
+		return
+	}
+
+	json.NewEncoder(response).Encode(income)
+}
@@ -25,6 +25,12 @@ var system = actors.GetSystem()
 func Start(reset bool) {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
+	version, err := constants.ReloadBalance()
+	if err != nil {
+		panic(err)
+	}
+	log.Printf("Loaded balance version %d", version)
+
 	if reset {
 		Reset()
 	}
@@ -44,14 +50,14 @@ func Start(reset bool) {
 	// time.Sleep(time.Second * 11)
 	// log.Printf("Created barracks with id %s", buildingId)
 
-	// err = services.TrainTroops(models.Training{
+	// _, err = services.TrainTroops(models.Training{
 	// 	BarracksId: buildingId,
 	// 	Size:       20,
 	// })
 
-	// time.Sleep(time.Second * (constants.TROOP_TRAINING_DURATION + 1))
+	// time.Sleep(time.Second * time.Duration(constants.GetTrainingDuration(constants.TROOP_TYPE_INFANTRY, 20, 1)+1))
 
-	// err = services.TrainTroops(models.Training{
+	// _, err = services.TrainTroops(models.Training{
 	// 	BarracksId: buildingId,
 	// 	Size:       10,
 	// 	DeployTo:   "164bab00-3fc7-41a8-bf76-22d6bba42f2a",
@@ -125,6 +131,30 @@ func Init() {
 		}
 	}
 	log.Printf("Spawned actors for %d buildings", len(buildings))
+
+	var constructions []models.Construction
+	db.Order("position").Find(&constructions)
+
+	cityConstructions := make(map[string][]models.Construction)
+	for _, construction := range constructions {
+		cityConstructions[construction.CityId] = append(cityConstructions[construction.CityId], construction)
+	}
+	for cityId, queue := range cityConstructions {
+		err := services.RestoreConstructions(cityId, queue)
+		if err != nil {
+			panic(err)
+		}
+	}
+	log.Printf("Restored %d constructions", len(constructions))
+
+	var orders []models.MarketOrder
+	db.Find(&orders)
+
+	err = services.RestoreOrders(orders)
+	if err != nil {
+		panic(err)
+	}
+	log.Printf("Restored %d market orders", len(orders))
 	log.Println("Initialization complete!")
 
 	log.SetPrefix("[app]\t")
@@ -147,6 +177,16 @@ func Reset() {
 		log.Fatalf("Error resetting Building table: %v", err)
 	}
 
+	err = resetTable(db, &models.Construction{})
+	if err != nil {
+		log.Fatalf("Error resetting Construction table: %v", err)
+	}
+
+	err = resetTable(db, &models.Training{})
+	if err != nil {
+		log.Fatalf("Error resetting Training table: %v", err)
+	}
+
 	err = resetTable(db, &models.City{})
 	if err != nil {
 		log.Fatalf("Error resetting City table: %v", err)
@@ -181,6 +221,7 @@ func Reset() {
 			Name:          fmt.Sprintf("%s's City", user.Username),
 			Population:    constants.INITIAL_PLAYER_CITY_POPULATION,
 			PopulationCap: constants.GetBuildingPopulation(constants.BUILDING_TYPE_CITY_CENTER, 1),
+			TaxRate:       constants.DEFAULT_TAX_RATE,
 			StartX:        startX,
 			StartY:        startY,
 			Size:          constants.CITY_SIZE,
@@ -245,6 +286,7 @@ func Reset() {
 						Name:          fmt.Sprintf("Town %s", cityId),
 						Population:    constants.INITIAL_TOWN_POPULATION,
 						PopulationCap: constants.GetBuildingPopulation(constants.BUILDING_TYPE_TOWN_CENTER, 1),
+						TaxRate:       constants.DEFAULT_TAX_RATE,
 						StartX:        x,
 						StartY:        y,
 						Size:          size,
@@ -266,9 +308,15 @@ func Reset() {
 				}
 			}
 
+			// cities are always built on plains
+			terrain := constants.TERRAIN_PLAINS
+			if !occupied[x][y] {
+				terrain = constants.GetTerrain(r.Intn(100))
+			}
 			mapTiles = append(mapTiles, models.MapTile{
-				X: x,
-				Y: y,
+				X:       x,
+				Y:       y,
+				Terrain: terrain,
 			})
 		}
 	}
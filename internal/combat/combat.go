@@ -0,0 +1,113 @@
+package combat
+
+import (
+	"cityio/internal/constants"
+	"cityio/internal/models"
+
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Resolve fights two sides against each other using Lanchester's square law.
+// The returned values are the share of troops of the attacker and defender that
+// survived; the losing side is always reduced to 0, and a draw destroys both sides.
+func Resolve(attackStrength float64, defenseStrength float64) (float64, float64) {
+	if attackStrength > defenseStrength {
+		ratio := defenseStrength / attackStrength
+		return math.Sqrt(1 - ratio*ratio), 0
+	} else if defenseStrength > attackStrength {
+		ratio := attackStrength / defenseStrength
+		return 0, math.Sqrt(1 - ratio*ratio)
+	}
+	return 0, 0
+}
+
+// Attack is the strength of troops attacking an army.
+func Attack(troops models.Troops) float64 {
+	strength := 0.0
+	for troopType, count := range troops {
+		strength += float64(count) * constants.GetTroopStats(troopType).Attack
+	}
+	return strength
+}
+
+// SiegeAttack is the strength of troops attacking a city garrison.
+func SiegeAttack(troops models.Troops) float64 {
+	strength := 0.0
+	for troopType, count := range troops {
+		strength += float64(count) * constants.GetTroopStats(troopType).SiegeAttack
+	}
+	return strength
+}
+
+// Defense is the strength of troops defending the tile they hold.
+func Defense(troops models.Troops) float64 {
+	strength := 0.0
+	for troopType, count := range troops {
+		strength += float64(count) * constants.GetTroopStats(troopType).Defense
+	}
+	return strength * constants.BATTLE_DEFENSE_BONUS
+}
+
+// Survivors scales every troop type down to the share that survived a battle.
+// Types with no units left are dropped from the composition.
+func Survivors(troops models.Troops, share float64) models.Troops {
+	result := make(models.Troops)
+	for troopType, count := range troops {
+		survivors := int64(math.Floor(float64(count) * share))
+		if survivors > 0 {
+			result[troopType] = survivors
+		}
+	}
+	return result
+}
+
+// Remove takes a number of units out of the troops, spread over the troop types in
+// proportion to their counts. The remainders left by rounding go to the types with the
+// largest fractions, so small types lose their fair share instead of all or nothing.
+func Remove(troops models.Troops, count int64) models.Troops {
+	total := troops.Total()
+	if count <= 0 {
+		return troops
+	}
+	if count >= total {
+		return make(models.Troops)
+	}
+
+	type share struct {
+		troopType string
+		fraction  float64
+	}
+	losses := make(map[string]int64)
+	shares := make([]share, 0, len(troops))
+	var assigned int64 = 0
+	for troopType, units := range troops {
+		exact := float64(count) * float64(units) / float64(total)
+		losses[troopType] = int64(math.Floor(exact))
+		assigned += losses[troopType]
+		shares = append(shares, share{troopType: troopType, fraction: exact - math.Floor(exact)})
+	}
+	slices.SortFunc(shares, func(a, b share) int {
+		if a.fraction != b.fraction {
+			return cmp.Compare(b.fraction, a.fraction)
+		}
+		return strings.Compare(a.troopType, b.troopType)
+	})
+	for i := 0; assigned < count; i = (i + 1) % len(shares) {
+		troopType := shares[i].troopType
+		if losses[troopType] < troops[troopType] {
+			losses[troopType]++
+			assigned++
+		}
+	}
+
+	result := make(models.Troops)
+	for troopType, units := range troops {
+		if left := units - losses[troopType]; left > 0 {
+			result[troopType] = left
+		}
+	}
+	return result
+}
@@ -0,0 +1,159 @@
+package combat
+
+import (
+	"cityio/internal/constants"
+	"cityio/internal/models"
+
+	"maps"
+	"math"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		attack   float64
+		defense  float64
+		attacker float64
+		defender float64
+	}{
+		{name: "attacker wins", attack: 5, defense: 3, attacker: 0.8, defender: 0},
+		{name: "defender wins", attack: 3, defense: 5, attacker: 0, defender: 0.8},
+		{name: "draw destroys both sides", attack: 4, defense: 4, attacker: 0, defender: 0},
+		{name: "undefended tile", attack: 10, defense: 0, attacker: 1, defender: 0},
+		{name: "overwhelming defense", attack: 1, defense: 100, attacker: 0, defender: math.Sqrt(1 - 0.0001)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attacker, defender := Resolve(test.attack, test.defense)
+			if math.Abs(attacker-test.attacker) > 1e-9 || math.Abs(defender-test.defender) > 1e-9 {
+				t.Errorf("Resolve(%v, %v) = %v, %v, expected %v, %v", test.attack, test.defense, attacker, defender, test.attacker, test.defender)
+			}
+		})
+	}
+}
+
+func TestStrength(t *testing.T) {
+	troops := models.Troops{constants.TROOP_TYPE_INFANTRY: 10, constants.TROOP_TYPE_SIEGE: 2}
+	infantry := constants.GetTroopStats(constants.TROOP_TYPE_INFANTRY)
+	siege := constants.GetTroopStats(constants.TROOP_TYPE_SIEGE)
+
+	tests := []struct {
+		name     string
+		strength func(models.Troops) float64
+		expected float64
+	}{
+		{name: "attack", strength: Attack, expected: 10*infantry.Attack + 2*siege.Attack},
+		{name: "siege attack", strength: SiegeAttack, expected: 10*infantry.SiegeAttack + 2*siege.SiegeAttack},
+		{name: "defense", strength: Defense, expected: (10*infantry.Defense + 2*siege.Defense) * constants.BATTLE_DEFENSE_BONUS},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if strength := test.strength(troops); math.Abs(strength-test.expected) > 1e-9 {
+				t.Errorf("strength of %v is %v, expected %v", troops, strength, test.expected)
+			}
+		})
+	}
+}
+
+func TestSurvivors(t *testing.T) {
+	tests := []struct {
+		name     string
+		troops   models.Troops
+		share    float64
+		expected models.Troops
+	}{
+		{
+			name:     "untouched",
+			troops:   models.Troops{constants.TROOP_TYPE_INFANTRY: 10},
+			share:    1,
+			expected: models.Troops{constants.TROOP_TYPE_INFANTRY: 10},
+		},
+		{
+			name:     "rounds down",
+			troops:   models.Troops{constants.TROOP_TYPE_INFANTRY: 10, constants.TROOP_TYPE_ARCHER: 5},
+			share:    0.75,
+			expected: models.Troops{constants.TROOP_TYPE_INFANTRY: 7, constants.TROOP_TYPE_ARCHER: 3},
+		},
+		{
+			name:     "drops wiped out types",
+			troops:   models.Troops{constants.TROOP_TYPE_INFANTRY: 10, constants.TROOP_TYPE_CAVALRY: 1},
+			share:    0.5,
+			expected: models.Troops{constants.TROOP_TYPE_INFANTRY: 5},
+		},
+		{
+			name:     "destroyed",
+			troops:   models.Troops{constants.TROOP_TYPE_INFANTRY: 10},
+			share:    0,
+			expected: models.Troops{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if survivors := Survivors(test.troops, test.share); !maps.Equal(survivors, test.expected) {
+				t.Errorf("Survivors(%v, %v) = %v, expected %v", test.troops, test.share, survivors, test.expected)
+			}
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	tests := []struct {
+		name     string
+		troops   models.Troops
+		count    int64
+		expected models.Troops
+	}{
+		{
+			name:     "nothing to remove",
+			troops:   models.Troops{constants.TROOP_TYPE_INFANTRY: 10},
+			count:    0,
+			expected: models.Troops{constants.TROOP_TYPE_INFANTRY: 10},
+		},
+		{
+			name:     "whole army",
+			troops:   models.Troops{constants.TROOP_TYPE_INFANTRY: 10, constants.TROOP_TYPE_ARCHER: 2},
+			count:    20,
+			expected: models.Troops{},
+		},
+		{
+			name:     "proportional",
+			troops:   models.Troops{constants.TROOP_TYPE_INFANTRY: 20, constants.TROOP_TYPE_ARCHER: 10},
+			count:    6,
+			expected: models.Troops{constants.TROOP_TYPE_INFANTRY: 16, constants.TROOP_TYPE_ARCHER: 8},
+		},
+		{
+			name:     "remainder goes to the largest fraction",
+			troops:   models.Troops{constants.TROOP_TYPE_INFANTRY: 98, constants.TROOP_TYPE_ARCHER: 1, constants.TROOP_TYPE_CAVALRY: 1},
+			count:    10,
+			expected: models.Troops{constants.TROOP_TYPE_INFANTRY: 88, constants.TROOP_TYPE_ARCHER: 1, constants.TROOP_TYPE_CAVALRY: 1},
+		},
+		{
+			name:     "small types lose their fair share",
+			troops:   models.Troops{constants.TROOP_TYPE_INFANTRY: 1, constants.TROOP_TYPE_ARCHER: 3},
+			count:    3,
+			expected: models.Troops{constants.TROOP_TYPE_ARCHER: 1},
+		},
+		{
+			name:     "single unit out of even types",
+			troops:   models.Troops{constants.TROOP_TYPE_INFANTRY: 1, constants.TROOP_TYPE_ARCHER: 1, constants.TROOP_TYPE_CAVALRY: 1},
+			count:    1,
+			expected: models.Troops{constants.TROOP_TYPE_INFANTRY: 1, constants.TROOP_TYPE_CAVALRY: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			left := Remove(test.troops, test.count)
+			if !maps.Equal(left, test.expected) {
+				t.Errorf("Remove(%v, %d) = %v, expected %v", test.troops, test.count, left, test.expected)
+			}
+			if removed := test.troops.Total() - left.Total(); removed != min(max(test.count, 0), test.troops.Total()) {
+				t.Errorf("Remove(%v, %d) removed %d units", test.troops, test.count, removed)
+			}
+		})
+	}
+}
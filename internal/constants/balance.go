@@ -0,0 +1,161 @@
+package constants
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"cityio/internal/messages"
+)
+
+// balance file compiled into the binary, used unless BALANCE_FILE points elsewhere
+//
+//go:embed balance.json
+var defaultBalance []byte
+
+type BuildingStats struct {
+	GoldCost         []int64            `json:"goldCost"`
+	FoodCost         []int64            `json:"foodCost"`
+	ConstructionTime []int64            `json:"constructionTime"`
+	Production       map[string][]int64 `json:"production,omitempty"`
+	Population       []float64          `json:"population,omitempty"`
+	Storage          map[string][]int64 `json:"storage,omitempty"`
+}
+
+type Balance struct {
+	Version   int                      `json:"version"`
+	Buildings map[string]BuildingStats `json:"buildings"`
+}
+
+var (
+	balance      *Balance
+	balanceMutex sync.RWMutex
+)
+
+func init() {
+	parsed, err := ParseBalance(defaultBalance)
+	if err != nil {
+		panic(err)
+	}
+	balance = parsed
+}
+
+// ParseBalance decodes a balance file and checks that every building type is
+// described with one non-decreasing, non-negative value per level.
+func ParseBalance(data []byte) (*Balance, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var parsed Balance
+	if err := decoder.Decode(&parsed); err != nil {
+		return nil, &messages.InvalidBalanceError{Reason: err.Error()}
+	}
+	if parsed.Version <= 0 {
+		return nil, &messages.InvalidBalanceError{Reason: "version must be positive"}
+	}
+
+	for _, buildingType := range buildingTypes {
+		if _, ok := parsed.Buildings[buildingType]; !ok {
+			return nil, &messages.InvalidBalanceError{Reason: fmt.Sprintf("missing building type %s", buildingType)}
+		}
+	}
+	for buildingType, stats := range parsed.Buildings {
+		if !IsBuildingType(buildingType) {
+			return nil, &messages.InvalidBalanceError{Reason: fmt.Sprintf("unknown building type %s", buildingType)}
+		}
+		if err := validateLevels(buildingType, "goldCost", stats.GoldCost); err != nil {
+			return nil, err
+		}
+		if err := validateLevels(buildingType, "foodCost", stats.FoodCost); err != nil {
+			return nil, err
+		}
+		if err := validateLevels(buildingType, "constructionTime", stats.ConstructionTime); err != nil {
+			return nil, err
+		}
+		if stats.Population != nil {
+			if err := validateLevels(buildingType, "population", stats.Population); err != nil {
+				return nil, err
+			}
+		}
+		for resource, amounts := range stats.Production {
+			if err := validateResource(buildingType, "production", resource, amounts); err != nil {
+				return nil, err
+			}
+		}
+		for resource, capacities := range stats.Storage {
+			if err := validateResource(buildingType, "storage", resource, capacities); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &parsed, nil
+}
+
+func validateResource(buildingType string, field string, resource string, values []int64) error {
+	if resource != RESOURCE_GOLD && resource != RESOURCE_FOOD {
+		return &messages.InvalidBalanceError{Reason: fmt.Sprintf("%s.%s has unknown resource %s", buildingType, field, resource)}
+	}
+	return validateLevels(buildingType, fmt.Sprintf("%s.%s", field, resource), values)
+}
+
+func validateLevels[T int64 | float64](buildingType string, field string, values []T) error {
+	if len(values) != MAX_BUILDING_LEVEL {
+		return &messages.InvalidBalanceError{
+			Reason: fmt.Sprintf("%s.%s has %d levels, expected %d", buildingType, field, len(values), MAX_BUILDING_LEVEL),
+		}
+	}
+	for i, value := range values {
+		if value < 0 {
+			return &messages.InvalidBalanceError{Reason: fmt.Sprintf("%s.%s level %d is negative", buildingType, field, i+1)}
+		}
+		if i > 0 && value < values[i-1] {
+			return &messages.InvalidBalanceError{Reason: fmt.Sprintf("%s.%s decreases at level %d", buildingType, field, i+1)}
+		}
+	}
+	return nil
+}
+
+// LoadBalance validates the balance file at path and swaps it in for the current one.
+func LoadBalance(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return setBalance(data)
+}
+
+// ReloadBalance loads the file named by BALANCE_FILE, or the embedded balance if it is unset.
+func ReloadBalance() (int, error) {
+	path := os.Getenv("BALANCE_FILE")
+	if path == "" {
+		return setBalance(defaultBalance)
+	}
+	return LoadBalance(path)
+}
+
+func GetBalanceVersion() int {
+	balanceMutex.RLock()
+	defer balanceMutex.RUnlock()
+	return balance.Version
+}
+
+func setBalance(data []byte) (int, error) {
+	parsed, err := ParseBalance(data)
+	if err != nil {
+		return 0, err
+	}
+	balanceMutex.Lock()
+	balance = parsed
+	balanceMutex.Unlock()
+	return parsed.Version, nil
+}
+
+func getBuildingStats(buildingType string) BuildingStats {
+	balanceMutex.RLock()
+	defer balanceMutex.RUnlock()
+	return balance.Buildings[buildingType]
+}
@@ -0,0 +1,138 @@
+package constants
+
+import (
+	"cityio/internal/messages"
+
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseBalance(t *testing.T) {
+	// every case edits a copy of the embedded balance, which has to be valid itself
+	edited := func(edit func(*Balance)) []byte {
+		parsed, err := ParseBalance(defaultBalance)
+		if err != nil {
+			t.Fatalf("embedded balance is invalid: %s", err)
+		}
+		edit(parsed)
+		data, err := json.Marshal(parsed)
+		if err != nil {
+			t.Fatalf("encoding balance: %s", err)
+		}
+		return data
+	}
+	editStats := func(buildingType string, edit func(*BuildingStats)) []byte {
+		return edited(func(balance *Balance) {
+			stats := balance.Buildings[buildingType]
+			edit(&stats)
+			balance.Buildings[buildingType] = stats
+		})
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		reason string // part of the expected error, empty when the balance is valid
+	}{
+		{
+			name: "embedded balance",
+			data: defaultBalance,
+		},
+		{
+			name:   "malformed json",
+			data:   []byte(`{"version": 1,`),
+			reason: "unexpected EOF",
+		},
+		{
+			name:   "unknown field",
+			data:   []byte(`{"version": 1, "buildings": {}, "troops": {}}`),
+			reason: "unknown field",
+		},
+		{
+			name:   "missing version",
+			data:   edited(func(balance *Balance) { balance.Version = 0 }),
+			reason: "version must be positive",
+		},
+		{
+			name:   "missing building type",
+			data:   edited(func(balance *Balance) { delete(balance.Buildings, BUILDING_TYPE_FARM) }),
+			reason: "missing building type farm",
+		},
+		{
+			name: "unknown building type",
+			data: edited(func(balance *Balance) {
+				balance.Buildings["castle"] = balance.Buildings[BUILDING_TYPE_HOUSE]
+			}),
+			reason: "unknown building type castle",
+		},
+		{
+			name: "missing level",
+			data: editStats(BUILDING_TYPE_HOUSE, func(stats *BuildingStats) {
+				stats.GoldCost = stats.GoldCost[:MAX_BUILDING_LEVEL-1]
+			}),
+			reason: "house.goldCost has 9 levels",
+		},
+		{
+			name: "negative value",
+			data: editStats(BUILDING_TYPE_HOUSE, func(stats *BuildingStats) {
+				stats.FoodCost = append([]int64{-1}, stats.FoodCost[1:]...)
+			}),
+			reason: "house.foodCost level 1 is negative",
+		},
+		{
+			name: "decreasing value",
+			data: editStats(BUILDING_TYPE_HOUSE, func(stats *BuildingStats) {
+				stats.ConstructionTime = append([]int64{}, stats.ConstructionTime...)
+				stats.ConstructionTime[MAX_BUILDING_LEVEL-1] = 0
+			}),
+			reason: "house.constructionTime decreases at level 10",
+		},
+		{
+			name: "decreasing population",
+			data: editStats(BUILDING_TYPE_HOUSE, func(stats *BuildingStats) {
+				stats.Population = append([]float64{}, stats.Population...)
+				stats.Population[1] = stats.Population[0] - 1
+			}),
+			reason: "house.population decreases at level 2",
+		},
+		{
+			name: "unknown produced resource",
+			data: editStats(BUILDING_TYPE_FARM, func(stats *BuildingStats) {
+				stats.Production = map[string][]int64{"wood": stats.Production[RESOURCE_FOOD]}
+			}),
+			reason: "farm.production has unknown resource wood",
+		},
+		{
+			name: "missing storage level",
+			data: editStats(BUILDING_TYPE_WAREHOUSE, func(stats *BuildingStats) {
+				stats.Storage = map[string][]int64{RESOURCE_GOLD: {1000}}
+			}),
+			reason: "warehouse.storage.gold has 1 levels",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parsed, err := ParseBalance(test.data)
+			if test.reason == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if parsed.Version <= 0 || len(parsed.Buildings) != len(buildingTypes) {
+					t.Errorf("unexpected balance %+v", parsed)
+				}
+				return
+			}
+
+			var invalid *messages.InvalidBalanceError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("expected an InvalidBalanceError, got %v", err)
+			}
+			if !strings.Contains(invalid.Reason, test.reason) {
+				t.Errorf("error %q does not mention %q", invalid.Reason, test.reason)
+			}
+		})
+	}
+}
@@ -1,5 +1,9 @@
 package constants
 
+import (
+	"slices"
+)
+
 const (
 	BUILDING_TYPE_CITY_CENTER = "city_center"
 	BUILDING_TYPE_TOWN_CENTER = "town_center"
@@ -7,55 +11,109 @@ const (
 	BUILDING_TYPE_HOUSE       = "house"
 	BUILDING_TYPE_FARM        = "farm"
 	BUILDING_TYPE_MINE        = "mine"
+	BUILDING_TYPE_WAREHOUSE   = "warehouse"
+	BUILDING_TYPE_GRANARY     = "granary"
+	BUILDING_TYPE_MARKET      = "market"
 
 	MAX_BUILDING_LEVEL = 10
 )
 
-var buildingProduction = map[string][]int64{
-	BUILDING_TYPE_CITY_CENTER: {100, 200, 300, 400, 500, 600, 700, 800, 900, 1000},
-	BUILDING_TYPE_TOWN_CENTER: {100, 200, 300, 400, 500, 600, 700, 800, 900, 1000},
-	BUILDING_TYPE_BARRACKS:    {50, 100, 150, 200, 250, 300, 350, 400, 450, 500},
-	BUILDING_TYPE_FARM:        {10, 20, 30, 40, 50, 60, 70, 80, 90, 100},
-	BUILDING_TYPE_MINE:        {30, 60, 90, 120, 150, 180, 210, 240, 270, 300},
+const (
+	RESOURCE_GOLD = "gold"
+	RESOURCE_FOOD = "food"
+)
+
+// every building type known to the game, each must be described by the balance file
+var buildingTypes = []string{
+	BUILDING_TYPE_CITY_CENTER,
+	BUILDING_TYPE_TOWN_CENTER,
+	BUILDING_TYPE_BARRACKS,
+	BUILDING_TYPE_HOUSE,
+	BUILDING_TYPE_FARM,
+	BUILDING_TYPE_MINE,
+	BUILDING_TYPE_WAREHOUSE,
+	BUILDING_TYPE_GRANARY,
+	BUILDING_TYPE_MARKET,
 }
 
-var buildingPopulation = map[string][]float64{
-	BUILDING_TYPE_CITY_CENTER: {1000, 2000, 3000, 4000, 5000, 6000, 7000, 8000, 9000, 10000},
-	BUILDING_TYPE_TOWN_CENTER: {1000, 200, 300, 400, 500, 600, 700, 800, 900, 10000},
-	BUILDING_TYPE_HOUSE:       {250, 500, 750, 1000, 1250, 1500, 1750, 2000, 2250, 2500},
+type BuildingCost struct {
+	Gold int64
+	Food int64
 }
 
-var buildingCosts = map[string][]int64{
-	BUILDING_TYPE_CITY_CENTER: {1000, 2000, 3000, 4000, 5000, 6000, 7000, 8000, 9000, 10000},
-	BUILDING_TYPE_TOWN_CENTER: {1000, 200, 300, 400, 500, 600, 700, 800, 900, 10000},
-	BUILDING_TYPE_BARRACKS:    {500, 1000, 1500, 2000, 2500, 3000, 3500, 4000, 4500, 5000},
-	BUILDING_TYPE_HOUSE:       {200, 400, 600, 800, 1000, 1200, 1400, 1600, 1800, 2000},
-	BUILDING_TYPE_FARM:        {100, 200, 300, 400, 500, 600, 700, 800, 900, 1000},
-	BUILDING_TYPE_MINE:        {300, 600, 900, 1200, 1500, 1800, 2100, 2400, 2700, 3000},
+type BuildingStorage struct {
+	Gold int64
+	Food int64
 }
 
-// in seconds
-var buildingConstructionTime = map[string][]int64{
-	BUILDING_TYPE_CITY_CENTER: {0, 20, 30, 40, 50, 60, 70, 80, 90, 100},
-	BUILDING_TYPE_TOWN_CENTER: {0, 20, 30, 40, 50, 60, 70, 80, 90, 100},
-	BUILDING_TYPE_BARRACKS:    {10, 20, 30, 40, 50, 60, 70, 80, 90, 100},
-	BUILDING_TYPE_HOUSE:       {5, 10, 15, 20, 25, 30, 35, 40, 45, 50},
-	BUILDING_TYPE_FARM:        {5, 10, 15, 20, 25, 30, 35, 40, 45, 50},
-	BUILDING_TYPE_MINE:        {5, 10, 15, 20, 25, 30, 35, 40, 45, 50},
+// maximum number of buildings of a type per city, types not listed are unlimited
+var buildingLimits = map[string]int{
+	BUILDING_TYPE_CITY_CENTER: 1,
+	BUILDING_TYPE_TOWN_CENTER: 1,
+	BUILDING_TYPE_BARRACKS:    2,
+	BUILDING_TYPE_MARKET:      1,
+}
+
+// building types that can only be placed in a certain type of city
+var buildingCityTypes = map[string]string{
+	BUILDING_TYPE_CITY_CENTER: "capital",
+	BUILDING_TYPE_TOWN_CENTER: "town",
 }
 
-func GetBuildingProduction(buildingType string, level int) int64 {
-	return buildingProduction[buildingType][level-1]
+// GetBuildingProduction returns the amount of each resource a building yields at a level.
+func GetBuildingProduction(buildingType string, level int) map[string]int64 {
+	stats := getBuildingStats(buildingType)
+	production := make(map[string]int64)
+	for resource, amounts := range stats.Production {
+		production[resource] = amounts[level-1]
+	}
+	return production
 }
 
 func GetBuildingPopulation(buildingType string, level int) float64 {
-	return buildingPopulation[buildingType][level-1]
+	stats := getBuildingStats(buildingType)
+	if stats.Population == nil {
+		return 0
+	}
+	return stats.Population[level-1]
 }
 
-func GetBuildingCost(buildingType string, level int) int64 {
-	return buildingCosts[buildingType][level-1]
+// GetBuildingStorage returns the extra gold and food a building lets its owner hold
+// on top of BASE_STORAGE_CAPACITY.
+func GetBuildingStorage(buildingType string, level int) BuildingStorage {
+	stats := getBuildingStats(buildingType)
+	storage := BuildingStorage{}
+	if capacities, ok := stats.Storage[RESOURCE_GOLD]; ok {
+		storage.Gold = capacities[level-1]
+	}
+	if capacities, ok := stats.Storage[RESOURCE_FOOD]; ok {
+		storage.Food = capacities[level-1]
+	}
+	return storage
 }
 
+func GetBuildingCost(buildingType string, level int) BuildingCost {
+	stats := getBuildingStats(buildingType)
+	return BuildingCost{
+		Gold: stats.GoldCost[level-1],
+		Food: stats.FoodCost[level-1],
+	}
+}
+
+func IsBuildingType(buildingType string) bool {
+	return slices.Contains(buildingTypes, buildingType)
+}
+
+// in seconds
 func GetBuildingConstructionTime(buildingType string, level int) int64 {
-	return buildingConstructionTime[buildingType][level-1]
+	return getBuildingStats(buildingType).ConstructionTime[level-1]
+}
+
+func GetBuildingLimit(buildingType string) int {
+	return buildingLimits[buildingType]
+}
+
+func IsBuildingAllowed(buildingType string, cityType string) bool {
+	requiredCityType, ok := buildingCityTypes[buildingType]
+	return !ok || requiredCityType == cityType
 }
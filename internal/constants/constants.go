@@ -4,7 +4,21 @@ const (
 	MAP_SIZE  = 128 // generate a map of size MAP_SIZE x MAP_SIZE
 	CITY_SIZE = 5
 
-	POPULATION_GROWTH_RATE = 0.001
+	VIEWPORT_MAX_SIZE = 25 // tiles along each side of the area a player can watch
+
+	CREDIT_RETRY_LIMIT = 12 // attempts before a payout is given up on and logged
+
+	WS_SEND_QUEUE_SIZE = 256 // frames waiting for a session before it is dropped as too slow
+	WS_REPLAY_SIZE     = 128 // updates kept for each player to replay after a reconnect, must fit the queue
+
+	POPULATION_GROWTH_RATE     = 0.001
+	POPULATION_FOOD_PER_CAPITA = 0.05 // food eaten per inhabitant on each city tick
+	POPULATION_STARVATION_RATE = 0.05 // share of the unfed population lost on each city tick
+
+	DEFAULT_TAX_RATE = 0.1
+	MAX_TAX_RATE     = 1.0
+	TAX_PER_CAPITA   = 1.0 // gold paid per inhabitant on each city tick at a tax rate of 1
+	TAX_GROWTH_LIMIT = 0.5 // tax rate at which population stops growing, higher rates shrink it
 
 	INITIAL_TOWN_POPULATION = 100
 
@@ -12,16 +26,76 @@ const (
 	INITIAL_PLAYER_GOLD            = 100000
 	INITIAL_PLAYER_FOOD            = 100000
 
+	BASE_STORAGE_CAPACITY = 200000 // gold and food a player can hold without any warehouses or granaries
+
+	CONSTRUCTION_REFUND_RATIO = 0.5 // share of the cost returned when a started construction is cancelled
+	CONSTRUCTION_SLOTS        = 2   // constructions a city builds in parallel
+	CONSTRUCTION_QUEUE_SIZE   = 10  // constructions a city can have queued, including the ones being built
+
 	TROOP_MOVEMENT_BACKUP_FREQUENCY = 5 // number of tile movements before state saved to db
+	TROOP_MOVEMENT_PROGRESS         = 2 // movement progress needed per point of terrain cost
+	CARAVAN_SPEED                   = 1 // movement progress of a caravan per tick, as slow as siege engines
+
+	TRAINING_LEVEL_SPEEDUP = 0.05 // share of the training time saved per barracks level above the first
+	TRAINING_REFUND_RATIO  = 0.5  // share of the cost returned when a started training is cancelled
+	TRAINING_QUEUE_SIZE    = 5    // batches a barracks can have queued, including the one being trained
+
+	MARKET_ORDER_LIMIT = 10 // open market orders a player can have at once
+
+	BATTLE_DEFENSE_BONUS = 1.2 // strength multiplier for armies already holding a tile
+	CITY_GARRISON_RATIO  = 0.1 // troops defending a city under siege per unit of population
+	ARMY_DESERTION_RATE  = 0.2 // share of the troops left without food that desert on each upkeep
 
 	// in seconds
 	DB_BACKUP_FREQUENCY           = 2  // frequency of database flushing buffer queue and writing to database
-	USER_BACKUP_FREQUENCY         = 10 // frequency of user state being sent to update queue
+	USER_BACKUP_FREQUENCY         = 10 // frequency of user state being sent to update queue and army upkeep being charged
 	CITY_BACKUP_FREQUENCY         = 10 // frequency of population growth event and city state being sent to update queue
 	BUILDING_PRODUCTION_FREQUENCY = 3  // frequency of building production
 
 	ACTOR_TIMEOUT_DURATION = 1 // timeout on actor response await
 
-	TROOP_TRAINING_DURATION = 5
-	TROOP_MOVEMENT_DURATION = 1 // time it takes to cross 1 tile
+	TROOP_MOVEMENT_DURATION = 1 // time between movement ticks
+
+	SIEGE_DURATION = 30 // time an army must hold a city center before the siege is decided
+
+	CREDIT_RETRY_DELAY = 5 // time between attempts to pay out a trade or caravan to a player
+
+	WS_WRITE_TIMEOUT = 10 // time a client gets to accept a frame before its session is closed
+)
+
+// changes pushed to players watching the map
+const (
+	MAP_EVENT_ARMY_ENTERED = "army_entered"
+	MAP_EVENT_ARMY_LEFT    = "army_left"
+	MAP_EVENT_ARMY_UPDATED = "army_updated" // troops, march or merges of armies already on the tile
+	MAP_EVENT_BUILDING     = "building"
+	MAP_EVENT_CITY         = "city"
+)
+
+const (
+	ARMY_KIND_TROOPS  = "army"
+	ARMY_KIND_CARAVAN = "caravan" // carries resources between cities instead of troops
+)
+
+const (
+	ORDER_SIDE_BUY  = "buy"  // buys food with gold
+	ORDER_SIDE_SELL = "sell" // sells food for gold
+)
+
+// reasons recorded in the ledger for changes to the gold and food of a player
+const (
+	LEDGER_REASON_PRODUCTION          = "production"
+	LEDGER_REASON_TAX                 = "tax"
+	LEDGER_REASON_POPULATION          = "population" // food eaten by the population of a city
+	LEDGER_REASON_UPKEEP              = "upkeep"     // food eaten by armies
+	LEDGER_REASON_CONSTRUCTION        = "construction"
+	LEDGER_REASON_CONSTRUCTION_REFUND = "construction_refund"
+	LEDGER_REASON_TRAINING            = "training"
+	LEDGER_REASON_TRAINING_REFUND     = "training_refund"
+	LEDGER_REASON_MARKET_ESCROW       = "market_escrow" // offered resources held by an open order
+	LEDGER_REASON_MARKET_TRADE        = "market_trade"
+	LEDGER_REASON_MARKET_REFUND       = "market_refund" // escrow left over when an order closes
+	LEDGER_REASON_CARAVAN             = "caravan"       // cargo loaded onto a caravan
+	LEDGER_REASON_CARAVAN_DELIVERY    = "caravan_delivery"
+	LEDGER_REASON_CARAVAN_ROBBERY     = "caravan_robbery"
 )
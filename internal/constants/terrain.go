@@ -0,0 +1,46 @@
+package constants
+
+const (
+	TERRAIN_PLAINS    = "plains"
+	TERRAIN_FOREST    = "forest"
+	TERRAIN_HILLS     = "hills"
+	TERRAIN_MOUNTAINS = "mountains"
+)
+
+// number of movement ticks needed to enter a tile, 0 marks impassable terrain
+var terrainCosts = map[string]int{
+	TERRAIN_PLAINS:    1,
+	TERRAIN_FOREST:    2,
+	TERRAIN_HILLS:     3,
+	TERRAIN_MOUNTAINS: 0,
+}
+
+// chance out of 100 of a tile being generated with each terrain
+var terrainWeights = []struct {
+	Terrain string
+	Weight  int
+}{
+	{TERRAIN_PLAINS, 70},
+	{TERRAIN_FOREST, 15},
+	{TERRAIN_HILLS, 10},
+	{TERRAIN_MOUNTAINS, 5},
+}
+
+func GetTerrainCost(terrain string) int {
+	cost, ok := terrainCosts[terrain]
+	if !ok {
+		return terrainCosts[TERRAIN_PLAINS]
+	}
+	return cost
+}
+
+// GetTerrain maps a roll in [0, 100) to a terrain type.
+func GetTerrain(roll int) string {
+	for _, terrain := range terrainWeights {
+		if roll < terrain.Weight {
+			return terrain.Terrain
+		}
+		roll -= terrain.Weight
+	}
+	return TERRAIN_PLAINS
+}
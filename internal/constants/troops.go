@@ -0,0 +1,47 @@
+package constants
+
+import (
+	"math"
+)
+
+const (
+	TROOP_TYPE_INFANTRY = "infantry"
+	TROOP_TYPE_ARCHER   = "archer"
+	TROOP_TYPE_CAVALRY  = "cavalry"
+	TROOP_TYPE_SIEGE    = "siege"
+)
+
+type TroopStats struct {
+	Attack      float64 // strength of a unit when attacking
+	Defense     float64 // strength of a unit when defending
+	SiegeAttack float64 // strength of a unit when attacking a city garrison
+	Speed       int     // movement progress made per movement tick
+	Upkeep      int64   // food consumed per unit
+	Gold        int64   // gold cost per unit
+	Food        int64   // food cost per unit
+
+	TrainingTime float64 // seconds per unit at the first barracks level
+}
+
+var troopStats = map[string]TroopStats{
+	TROOP_TYPE_INFANTRY: {Attack: 1, Defense: 1.2, SiegeAttack: 1, Speed: 2, Upkeep: 1, Gold: 10, Food: 5, TrainingTime: 0.5},
+	TROOP_TYPE_ARCHER:   {Attack: 1.4, Defense: 0.8, SiegeAttack: 1, Speed: 2, Upkeep: 1, Gold: 15, Food: 5, TrainingTime: 0.8},
+	TROOP_TYPE_CAVALRY:  {Attack: 1.8, Defense: 1, SiegeAttack: 0.5, Speed: 4, Upkeep: 2, Gold: 30, Food: 10, TrainingTime: 1},
+	TROOP_TYPE_SIEGE:    {Attack: 0.5, Defense: 0.5, SiegeAttack: 4, Speed: 1, Upkeep: 3, Gold: 50, Food: 0, TrainingTime: 1.5},
+}
+
+func GetTroopStats(troopType string) TroopStats {
+	return troopStats[troopType]
+}
+
+func IsTroopType(troopType string) bool {
+	_, ok := troopStats[troopType]
+	return ok
+}
+
+// GetTrainingDuration returns the seconds needed to train a batch, higher barracks
+// levels train faster
+func GetTrainingDuration(troopType string, size int64, level int) int64 {
+	speedup := 1 - TRAINING_LEVEL_SPEEDUP*float64(level-1)
+	return int64(math.Ceil(troopStats[troopType].TrainingTime * float64(size) * speedup))
+}
@@ -75,6 +75,11 @@ func initDb() {
 		panic(err)
 	}
 
+	err = migrateTrainings(db)
+	if err != nil {
+		log.Fatal("Failed to migrate trainings:", err)
+	}
+
 	err = db.AutoMigrate(
 		&models.User{},
 		&models.Army{},
@@ -82,6 +87,10 @@ func initDb() {
 		&models.City{},
 		&models.Building{},
 		&models.Training{},
+		&models.Construction{},
+		&models.MarketOrder{},
+		&models.Trade{},
+		&models.LedgerEntry{},
 	)
 	if err != nil {
 		log.Fatal("Failed to auto-migrate:", err)
@@ -97,6 +106,32 @@ func initDb() {
 	psqlDb.SetConnMaxLifetime(0)
 }
 
+// trainings used to be keyed by their barracks, one batch each. AutoMigrate can neither
+// move a primary key nor add a required column to a table with rows, so existing rows
+// are given ids here before the key moves to training_id.
+func migrateTrainings(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.Training{}) || migrator.HasColumn(&models.Training{}, "TrainingId") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			`ALTER TABLE trainings ADD COLUMN training_id varchar(36)`,
+			`UPDATE trainings SET training_id = md5(random()::text || clock_timestamp()::text || barracks_id)::uuid::text`,
+			`ALTER TABLE trainings DROP CONSTRAINT IF EXISTS trainings_pkey`,
+			`ALTER TABLE trainings ADD PRIMARY KEY (training_id)`,
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		log.Println("Migrated trainings to be keyed by training_id")
+		return nil
+	})
+}
+
 func GetDb() *gorm.DB {
 	once.Do(initDb)
 	return db
@@ -4,16 +4,21 @@ import (
 	"cityio/internal/models"
 
 	"fmt"
+	"time"
 )
 
 type CreateArmyMessage struct {
 	Army    models.Army
 	Restore bool
+	NoMerge bool
 }
 type GetArmyMessage struct{}
 type UpdateArmyMessage struct {
 	Army models.Army
 }
+type UpdateArmyTroopsMessage struct {
+	Troops models.Troops
+}
 type DeleteArmyMessage struct {
 	ArmyId string
 }
@@ -21,7 +26,18 @@ type StartArmyMarchMessage struct {
 	X int
 	Y int
 }
+type RedirectArmyMarchMessage struct {
+	X int
+	Y int
+}
+type CancelArmyMarchMessage struct{}
 type UpdateArmyTileMessage struct{}
+type PayArmyUpkeepMessage struct{}
+type UnloadCaravanMessage struct {
+	Recipient string
+	Robbed    bool
+	Attempt   int // earlier attempts to pay the recipient that failed
+}
 
 type CreateArmyResponseMessage struct {
 	Error error
@@ -32,10 +48,25 @@ type GetArmyResponseMessage struct {
 type UpdateArmyResponseMessage struct {
 	Error error
 }
+type UpdateArmyTroopsResponseMessage struct {
+	Error error
+}
 type DeleteArmyResponseMessage struct {
 	Error error
 }
 
+type StartArmyMarchResponseMessage struct {
+	Arrival time.Time
+	Error   error
+}
+type RedirectArmyMarchResponseMessage struct {
+	Arrival time.Time
+	Error   error
+}
+type CancelArmyMarchResponseMessage struct {
+	Error error
+}
+
 // Errors
 type ArmyNotFoundError struct {
 	ArmyId string
@@ -44,3 +75,63 @@ type ArmyNotFoundError struct {
 func (e *ArmyNotFoundError) Error() string {
 	return fmt.Sprintf("Army not found: %s", e.ArmyId)
 }
+
+type ArmyNotOwnedError struct {
+	ArmyId string
+	UserId string
+}
+
+func (e *ArmyNotOwnedError) Error() string {
+	return fmt.Sprintf("Army %s is not owned by user: %s", e.ArmyId, e.UserId)
+}
+
+type ArmyAlreadyMarchingError struct {
+	ArmyId string
+}
+
+func (e *ArmyAlreadyMarchingError) Error() string {
+	return fmt.Sprintf("Army is already marching: %s", e.ArmyId)
+}
+
+type ArmyNotMarchingError struct {
+	ArmyId string
+}
+
+func (e *ArmyNotMarchingError) Error() string {
+	return fmt.Sprintf("Army is not marching: %s", e.ArmyId)
+}
+
+type NoPathError struct {
+	FromX int
+	FromY int
+	ToX   int
+	ToY   int
+}
+
+func (e *NoPathError) Error() string {
+	return fmt.Sprintf("No path from (%d, %d) to (%d, %d)", e.FromX, e.FromY, e.ToX, e.ToY)
+}
+
+type InsufficientTroopsError struct {
+	ArmyId string
+}
+
+func (e *InsufficientTroopsError) Error() string {
+	return fmt.Sprintf("Army does not have enough troops: %s", e.ArmyId)
+}
+
+type TooFewArmiesError struct {
+	Count int
+}
+
+func (e *TooFewArmiesError) Error() string {
+	return fmt.Sprintf("At least two armies are needed to merge, got %d", e.Count)
+}
+
+type InvalidCaravanError struct {
+	Reason string
+}
+
+func (e *InvalidCaravanError) Error() string {
+	return fmt.Sprintf("Invalid caravan: %s", e.Reason)
+}
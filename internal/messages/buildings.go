@@ -10,7 +10,9 @@ type CreateBuildingMessage struct {
 	Building models.Building
 	Restore  bool
 }
-type UpgradeBuildingMessage struct{}
+type CompleteBuildingLevelMessage struct {
+	Level int
+}
 type GetBuildingMessage struct{}
 type UpdateBuildingMessage struct {
 	Building models.Building
@@ -22,18 +24,31 @@ type TrainTroopsMessage struct {
 	Training models.Training
 }
 type RestoreTrainingMessage struct {
+	Trainings []models.Training
+}
+type CancelTrainingMessage struct {
+	TrainingId string
+}
+
+// ClearTrainingsMessage cancels the whole queue and refunds it to the given user, who
+// paid for it, e.g. the previous owner of a captured city
+type ClearTrainingsMessage struct {
+	UserId string
+}
+type GetTrainingsMessage struct{}
+type CompleteTrainingMessage struct {
+	TrainingId string
+}
+type UpdateTrainingMessage struct {
 	Training models.Training
 }
 type DeleteTrainingMessage struct {
-	BarracksId string
+	TrainingId string
 }
 
 type CreateBuildingResponseMessage struct {
 	Error error
 }
-type UpgradeBuildingResponseMessage struct {
-	Error error
-}
 type GetBuildingResponseMessage struct {
 	Building models.Building
 }
@@ -41,8 +56,15 @@ type DeleteBuildingResponseMessage struct {
 	Error error
 }
 type TrainTroopsResponseMessage struct {
+	Training models.Training
+	Error    error
+}
+type CancelTrainingResponseMessage struct {
 	Error error
 }
+type GetTrainingsResponseMessage struct {
+	Trainings []models.TrainingOutput
+}
 type RestoreTrainingResponseMessage struct {
 	Error error
 }
@@ -64,12 +86,45 @@ func (e *BuildingNotFoundError) Error() string {
 	return fmt.Sprintf("Building not found: %s", e.BuildingId)
 }
 
-type TrainingAlreadyExistsError struct {
+type TrainingNotFoundError struct {
+	TrainingId string
+}
+
+func (e *TrainingNotFoundError) Error() string {
+	return fmt.Sprintf("Training not found: %s", e.TrainingId)
+}
+
+type TrainingQueueFullError struct {
 	BarracksId string
+	Size       int
+}
+
+func (e *TrainingQueueFullError) Error() string {
+	return fmt.Sprintf("Barracks %s already has %d trainings queued", e.BarracksId, e.Size)
+}
+
+type InvalidTrainingSizeError struct {
+	Size int64
+}
+
+func (e *InvalidTrainingSizeError) Error() string {
+	return fmt.Sprintf("Invalid training size %d, must be positive", e.Size)
+}
+
+type NotBarracksError struct {
+	BuildingId string
+}
+
+func (e *NotBarracksError) Error() string {
+	return fmt.Sprintf("Building is not a barracks: %s", e.BuildingId)
 }
 
-func (e *TrainingAlreadyExistsError) Error() string {
-	return fmt.Sprintf("Training already exists for barracks: %s", e.BarracksId)
+type InvalidTroopTypeError struct {
+	TroopType string
+}
+
+func (e *InvalidTroopTypeError) Error() string {
+	return fmt.Sprintf("Invalid troop type: %s", e.TroopType)
 }
 
 type MaxLevelReachedError struct {
@@ -79,3 +134,49 @@ type MaxLevelReachedError struct {
 func (e *MaxLevelReachedError) Error() string {
 	return fmt.Sprintf("Max level reached for building: %s", e.BuildingId)
 }
+
+type TileOutsideCityError struct {
+	CityId string
+	X      int
+	Y      int
+}
+
+func (e *TileOutsideCityError) Error() string {
+	return fmt.Sprintf("Map tile %d,%d is not part of city: %s", e.X, e.Y, e.CityId)
+}
+
+type BuildingTypeNotAllowedError struct {
+	BuildingType string
+	CityType     string
+}
+
+func (e *BuildingTypeNotAllowedError) Error() string {
+	return fmt.Sprintf("Building type %s cannot be placed in a %s", e.BuildingType, e.CityType)
+}
+
+type BuildingLimitReachedError struct {
+	CityId       string
+	BuildingType string
+	Limit        int
+}
+
+func (e *BuildingLimitReachedError) Error() string {
+	return fmt.Sprintf("City %s already has %d buildings of type: %s", e.CityId, e.Limit, e.BuildingType)
+}
+
+type BuildingNotDemolishableError struct {
+	BuildingId   string
+	BuildingType string
+}
+
+func (e *BuildingNotDemolishableError) Error() string {
+	return fmt.Sprintf("Building %s of type %s cannot be demolished", e.BuildingId, e.BuildingType)
+}
+
+type BuildingUnderConstructionError struct {
+	BuildingId string
+}
+
+func (e *BuildingUnderConstructionError) Error() string {
+	return fmt.Sprintf("Building is still under construction: %s", e.BuildingId)
+}
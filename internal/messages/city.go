@@ -23,6 +23,56 @@ type UpdateOwnerPIDMessage struct {
 type UpdateCityPopulationCapMessage struct {
 	Change float64
 }
+type UpdateCityTaxRateMessage struct {
+	TaxRate float64
+}
+
+// AddCityBuildingMessage claims a tile of the city for a building, unless the tile is
+// taken or the city already has as many buildings of the type as it may
+type AddCityBuildingMessage struct {
+	BuildingId   string
+	BuildingType string
+	X            int
+	Y            int
+	Restore      bool // skips the checks for buildings loaded from the database
+}
+type RemoveCityBuildingMessage struct {
+	BuildingId string
+}
+type GetCityBuildingsMessage struct{}
+type StartSiegeMessage struct {
+	X       int
+	Y       int
+	ArmyPID *actor.PID
+	Army    models.Army
+}
+type ResolveSiegeMessage struct{}
+type QueueConstructionMessage struct {
+	Building models.Building
+}
+type CancelConstructionMessage struct {
+	ConstructionId string
+}
+type ReorderConstructionMessage struct {
+	ConstructionId string
+	Position       int
+}
+type GetConstructionsMessage struct{}
+type RestoreConstructionsMessage struct {
+	Constructions []models.Construction
+}
+type CompleteConstructionMessage struct {
+	ConstructionId string
+}
+type CreateConstructionMessage struct {
+	Construction models.Construction
+}
+type UpdateConstructionMessage struct {
+	Construction models.Construction
+}
+type DeleteConstructionMessage struct {
+	ConstructionId string
+}
 type GetCityMessage struct{}
 type DeleteCityMessage struct {
 	CityId string
@@ -34,7 +84,36 @@ type CreateCityResponseMessage struct {
 type UpdateCityPopulationCapResponseMessage struct {
 	Error error
 }
-
+type UpdateCityTaxRateResponseMessage struct {
+	City  models.City
+	Error error
+}
+type AddCityBuildingResponseMessage struct {
+	Error error
+}
+type RemoveCityBuildingResponseMessage struct {
+	Error error
+}
+type GetCityBuildingsResponseMessage struct {
+	Buildings map[string]string // building id to building type
+}
+type QueueConstructionResponseMessage struct {
+	Construction models.Construction
+	Error        error
+}
+type CancelConstructionResponseMessage struct {
+	DemolishBuildingId string // set when the cancelled construction was the first level of its building
+	Error              error
+}
+type ReorderConstructionResponseMessage struct {
+	Error error
+}
+type GetConstructionsResponseMessage struct {
+	Constructions []models.ConstructionOutput
+}
+type RestoreConstructionsResponseMessage struct {
+	Error error
+}
 type GetCityResponseMessage struct {
 	City models.City
 }
@@ -50,3 +129,54 @@ type CityNotFoundError struct {
 func (e *CityNotFoundError) Error() string {
 	return fmt.Sprintf("City not found: %s", e.CityId)
 }
+
+type CityNotOwnedError struct {
+	CityId string
+	UserId string
+}
+
+func (e *CityNotOwnedError) Error() string {
+	return fmt.Sprintf("City %s is not owned by user: %s", e.CityId, e.UserId)
+}
+
+type InvalidTaxRateError struct {
+	TaxRate float64
+}
+
+func (e *InvalidTaxRateError) Error() string {
+	return fmt.Sprintf("Invalid tax rate: %g", e.TaxRate)
+}
+
+type ConstructionNotFoundError struct {
+	ConstructionId string
+}
+
+func (e *ConstructionNotFoundError) Error() string {
+	return fmt.Sprintf("Construction not found: %s", e.ConstructionId)
+}
+
+type ConstructionInProgressError struct {
+	ConstructionId string
+}
+
+func (e *ConstructionInProgressError) Error() string {
+	return fmt.Sprintf("Construction %s has already started", e.ConstructionId)
+}
+
+type ConstructionQueueFullError struct {
+	CityId string
+	Size   int
+}
+
+func (e *ConstructionQueueFullError) Error() string {
+	return fmt.Sprintf("City %s already has %d constructions queued", e.CityId, e.Size)
+}
+
+type InvalidQueuePositionError struct {
+	ConstructionId string
+	Position       int
+}
+
+func (e *InvalidQueuePositionError) Error() string {
+	return fmt.Sprintf("Construction %s cannot be moved to position %d", e.ConstructionId, e.Position)
+}
@@ -24,3 +24,35 @@ type UnknownError struct {
 func (e *UnknownError) Error() string {
 	return fmt.Sprintf("Unknown error: %s", e.Message)
 }
+
+type InvalidBalanceError struct {
+	Reason string
+}
+
+func (e *InvalidBalanceError) Error() string {
+	return fmt.Sprintf("Invalid balance file: %s", e.Reason)
+}
+
+type InvalidRequestError struct {
+	Reason string
+}
+
+func (e *InvalidRequestError) Error() string {
+	return fmt.Sprintf("Invalid request: %s", e.Reason)
+}
+
+type UnknownRequestError struct {
+	Req int
+}
+
+func (e *UnknownRequestError) Error() string {
+	return fmt.Sprintf("Unknown request: %d", e.Req)
+}
+
+type SessionClosedError struct {
+	SessionId string
+}
+
+func (e *SessionClosedError) Error() string {
+	return fmt.Sprintf("WebSocket session %s is closed", e.SessionId)
+}
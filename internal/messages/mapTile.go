@@ -12,20 +12,45 @@ type CreateMapTileMessage struct {
 	Tile    models.MapTile
 	Restore bool
 }
+type UpdateMapTileMessage struct {
+	Tile models.MapTile
+}
 type AddCityToTileMessage struct {
 	CityId string
 }
 type AddBuildingToTileMessage struct {
 	BuildingId string
 }
+type RemoveBuildingFromTileMessage struct {
+	BuildingId string
+}
 type AddTileArmyMessage struct {
 	ArmyPID *actor.PID
 	Army    models.Army
+	NoMerge bool // keeps the army apart from other idle armies, e.g. after a split
+}
+type UpdateTileArmyMessage struct {
+	Army models.Army
 }
 type RemoveTileArmyMessage struct {
 	Owner  string
 	ArmyId string
 }
+type SplitTileArmyMessage struct {
+	Owner  string
+	ArmyId string
+	Troops models.Troops
+}
+type MergeTileArmiesMessage struct {
+	Owner   string
+	ArmyIds []string
+}
+type TransferTileArmyMessage struct {
+	Owner     string
+	ArmyId    string
+	Recipient string
+	Troops    models.Troops
+}
 type GetMapTileMessage struct{}
 type GetMapTileArmiesMessage struct{}
 
@@ -38,17 +63,32 @@ type AddCityToTileResponseMessage struct {
 type AddBuildingToTileResponseMessage struct {
 	Error error
 }
+type RemoveBuildingFromTileResponseMessage struct {
+	Error error
+}
 type AddTileArmyResponseMessage struct {
 	Error error
 }
 type RemoveTileArmyResponseMessage struct {
 	Error error
 }
+type SplitTileArmyResponseMessage struct {
+	ArmyId string
+	Error  error
+}
+type MergeTileArmiesResponseMessage struct {
+	ArmyId string
+	Error  error
+}
+type TransferTileArmyResponseMessage struct {
+	Error error
+}
 type GetMapTileResponseMessage struct {
 	Tile     models.MapTile
 	City     *models.City
 	Building *models.Building
 	Armies   map[string][]*models.Army
+	Caravans map[string][]*models.Army
 }
 type GetMapTileArmiesResponseMessage struct {
 	Armies map[string][]*models.Army
@@ -63,3 +103,32 @@ type MapTileNotFoundError struct {
 func (e *MapTileNotFoundError) Error() string {
 	return fmt.Sprintf("Map tile not found: %d,%d", e.X, e.Y)
 }
+
+type InvalidCoordinatesError struct {
+	X int
+	Y int
+}
+
+func (e *InvalidCoordinatesError) Error() string {
+	return fmt.Sprintf("Invalid coordinates: %d,%d", e.X, e.Y)
+}
+
+type TileOccupiedError struct {
+	X          int
+	Y          int
+	BuildingId string
+}
+
+func (e *TileOccupiedError) Error() string {
+	return fmt.Sprintf("Map tile %d,%d is occupied by building: %s", e.X, e.Y, e.BuildingId)
+}
+
+type NoArmyOnTileError struct {
+	UserId string
+	X      int
+	Y      int
+}
+
+func (e *NoArmyOnTileError) Error() string {
+	return fmt.Sprintf("User %s has no idle army on map tile %d,%d", e.UserId, e.X, e.Y)
+}
@@ -0,0 +1,94 @@
+package messages
+
+import (
+	"cityio/internal/models"
+
+	"fmt"
+)
+
+type PlaceOrderMessage struct {
+	Order models.MarketOrder
+}
+type CancelOrderMessage struct {
+	OrderId string
+	UserId  string
+}
+type GetOrdersMessage struct{}
+type RestoreOrdersMessage struct {
+	Orders []models.MarketOrder
+}
+type CreateOrderMessage struct {
+	Order models.MarketOrder
+}
+type UpdateOrderMessage struct {
+	Order models.MarketOrder
+}
+type DeleteOrderMessage struct {
+	OrderId string
+}
+type CreateTradeMessage struct {
+	Trade models.Trade
+}
+type RetryCreditMessage struct {
+	UserId  string
+	Credit  CreditResourcesMessage
+	Attempt int
+}
+
+type PlaceOrderResponseMessage struct {
+	Order  models.MarketOrder
+	Trades []models.Trade
+	Error  error
+}
+type CancelOrderResponseMessage struct {
+	Error error
+}
+type GetOrdersResponseMessage struct {
+	Orders []models.MarketOrder
+}
+type RestoreOrdersResponseMessage struct {
+	Error error
+}
+
+// Errors
+type OrderNotFoundError struct {
+	OrderId string
+}
+
+func (e *OrderNotFoundError) Error() string {
+	return fmt.Sprintf("Order not found: %s", e.OrderId)
+}
+
+type OrderNotOwnedError struct {
+	OrderId string
+	UserId  string
+}
+
+func (e *OrderNotOwnedError) Error() string {
+	return fmt.Sprintf("Order %s is not owned by user: %s", e.OrderId, e.UserId)
+}
+
+type InvalidOrderError struct {
+	Reason string
+}
+
+func (e *InvalidOrderError) Error() string {
+	return fmt.Sprintf("Invalid order: %s", e.Reason)
+}
+
+type OrderLimitReachedError struct {
+	UserId string
+	Limit  int
+}
+
+func (e *OrderLimitReachedError) Error() string {
+	return fmt.Sprintf("User %s already has %d open orders", e.UserId, e.Limit)
+}
+
+type MarketRequiredError struct {
+	CityId string
+}
+
+func (e *MarketRequiredError) Error() string {
+	return fmt.Sprintf("City %s has no finished market", e.CityId)
+}
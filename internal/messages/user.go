@@ -23,18 +23,62 @@ type RemoveAllyMessage struct {
 }
 type UpdateUserGoldMessage struct {
 	Change int64
+	Reason string // why the resources changed, recorded in the ledger with the id of the source
+	Source string
 }
 type UpdateUserFoodMessage struct {
 	Change int64
+	Reason string
+	Source string
+}
+
+// SpendResourcesMessage takes gold and food together, or nothing if either falls short
+type SpendResourcesMessage struct {
+	Gold   int64
+	Food   int64
+	Reason string
+	Source string
+}
+
+// CreditResourcesMessage pays out gold and food that already left another account, e.g.
+// market escrow or caravan cargo, so storage caps do not apply
+type CreditResourcesMessage struct {
+	Gold   int64
+	Food   int64
+	Reason string
+	Source string
+}
+type SetUserStorageMessage struct {
+	BuildingId string
+	Gold       int64
+	Food       int64
+}
+type RemoveUserStorageMessage struct {
+	BuildingId string
+}
+type ConsumeFoodMessage struct {
+	Amount int64
+	Reason string
+	Source string
+}
+type UpdateUserSettingsMessage struct {
+	DisableAutoMerge bool
 }
 type GetUserMessage struct{}
+type GetUserAccountMessage struct{}
 type AddUserArmyMessage struct {
 	ArmyId  string
 	ArmyPID *actor.PID
 }
+type RemoveUserArmyMessage struct {
+	ArmyId string
+}
 type DeleteUserMessage struct {
 	UserId string
 }
+type CreateLedgerEntryMessage struct {
+	Entry models.LedgerEntry
+}
 
 type RegisterUserResponseMessage struct {
 	Error error
@@ -51,12 +95,36 @@ type UpdateUserGoldResponseMessage struct {
 type UpdateUserFoodResponseMessage struct {
 	Error error
 }
+type SpendResourcesResponseMessage struct {
+	Error error
+}
+type CreditResourcesResponseMessage struct {
+	Error error
+}
+type SetUserStorageResponseMessage struct {
+	Error error
+}
+type RemoveUserStorageResponseMessage struct {
+	Error error
+}
+type ConsumeFoodResponseMessage struct {
+	Consumed int64 // may be less than requested if the user ran out of food
+}
+type UpdateUserSettingsResponseMessage struct {
+	Error error
+}
 type GetUserResponseMessage struct {
 	User models.User
 }
+type GetUserAccountResponseMessage struct {
+	Account models.UserAccountOutput
+}
 type AddUserArmyResponseMessage struct {
 	Error error
 }
+type RemoveUserArmyResponseMessage struct {
+	Error error
+}
 type DeleteUserResponseMessage struct {
 	Error error
 }
@@ -91,3 +159,32 @@ type UserCreationError struct {
 func (e *UserCreationError) Error() string {
 	return fmt.Sprintf("Error creating user: %s", e.UserId)
 }
+
+type InsufficientResourcesError struct {
+	UserId string
+	Gold   int64
+	Food   int64
+}
+
+func (e *InsufficientResourcesError) Error() string {
+	return fmt.Sprintf("Insufficient resources for user %s: requires %d gold and %d food", e.UserId, e.Gold, e.Food)
+}
+
+type InvalidResourceAmountError struct {
+	UserId string
+	Gold   int64
+	Food   int64
+}
+
+func (e *InvalidResourceAmountError) Error() string {
+	return fmt.Sprintf("Invalid resource amounts for user %s: %d gold and %d food", e.UserId, e.Gold, e.Food)
+}
+
+type NotAlliedError struct {
+	UserId string
+	AllyId string
+}
+
+func (e *NotAlliedError) Error() string {
+	return fmt.Sprintf("User %s is not allied with user: %s", e.UserId, e.AllyId)
+}
@@ -6,18 +6,63 @@ const (
 
 	WS_REQ_USER = 1100
 
-	WS_REQ_MAP = 2000
+	WS_REQ_MAP             = 2000
+	WS_REQ_MAP_SUBSCRIBE   = 2002 // replaces the viewport of the player, if any
+	WS_REQ_MAP_UNSUBSCRIBE = 2004
 
 	WS_REQ_CITY = 2100
+
+	WS_REQ_BUILDING_CONSTRUCT = 2200
+	WS_REQ_BUILDING_UPGRADE   = 2202
+	WS_REQ_BUILDING_DEMOLISH  = 2204
+
+	WS_REQ_CONSTRUCTION_CANCEL  = 2206
+	WS_REQ_CONSTRUCTION_REORDER = 2208
+	WS_REQ_TRAINING_QUEUE       = 2210
+	WS_REQ_TRAINING_CANCEL      = 2212
+
+	WS_REQ_ARMY_MARCH        = 2300
+	WS_REQ_ARMY_REDIRECT     = 2302
+	WS_REQ_ARMY_CANCEL_MARCH = 2304
+	WS_REQ_ARMY_SPLIT        = 2306
+	WS_REQ_ARMY_MERGE        = 2308
+	WS_REQ_ARMY_TRANSFER     = 2310
+	WS_REQ_CARAVAN_SEND      = 2312
+
+	WS_REQ_MARKET_PLACE  = 2500
+	WS_REQ_MARKET_CANCEL = 2502
 )
 
 // response codes
 const (
-	WS_PONG = 1001
+	WS_PONG   = 1001
+	WS_OK     = 1003 // reply to a request carrying its result, if any
+	WS_ERROR  = 1005 // reply to a request that failed
+	WS_RESYNC = 1007 // updates missed while disconnected can no longer be replayed
 
-	WS_USER = 1101
+	WS_USER       = 1101
+	WS_STARVATION = 1103
 
-	WS_MAP = 2001
+	WS_MAP       = 2001
+	WS_MAP_DELTA = 2003 // change to a tile in the viewport of the player
 
 	WS_CITY = 2101
+
+	WS_BUILDING            = 2201
+	WS_BUILDING_DEMOLISHED = 2205
+
+	WS_CONSTRUCTIONS         = 2207 // queue of a city whenever it changes
+	WS_CONSTRUCTION_COMPLETE = 2209
+	WS_TRAININGS             = 2211 // queue of a barracks whenever it changes
+	WS_TRAINING_COMPLETE     = 2213
+
+	WS_ARMY        = 2301
+	WS_ARMY_TROOPS = 2303
+	WS_CARAVAN     = 2313 // a caravan was delivered or robbed
+
+	WS_BATTLE = 2401
+	WS_SIEGE  = 2403
+
+	WS_ORDER = 2501 // an order of the player was placed, filled or cancelled
+	WS_TRADE = 2503
 )
@@ -1,8 +1,13 @@
 package models
 
+import (
+	"encoding/json"
+)
+
 type WebSocketRequest struct {
-	Req  int         `json:"req"`
-	Data interface{} `json:"data"`
+	Id   json.RawMessage `json:"id,omitempty"` // chosen by the client and echoed in the reply
+	Req  int             `json:"req"`
+	Data interface{}     `json:"data"`
 }
 
 type UserClaims struct {
@@ -34,3 +39,85 @@ type MapTileRequest struct {
 	Y      int `json:"y"`
 	Radius int `json:"radius"`
 }
+
+// Viewport is a rectangle of the map starting at its top left tile
+type Viewport struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+func (viewport Viewport) Overlaps(other Viewport) bool {
+	return viewport.X < other.X+other.Width && other.X < viewport.X+viewport.Width &&
+		viewport.Y < other.Y+other.Height && other.Y < viewport.Y+viewport.Height
+}
+
+type BuildingInput struct {
+	CityId string `json:"cityId"`
+	Type   string `json:"type"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+}
+
+type BuildingRequest struct {
+	BuildingId string `json:"buildingId"`
+}
+
+type ArmyMarchRequest struct {
+	ArmyId string `json:"armyId"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+}
+
+type ArmySplitRequest struct {
+	ArmyId string `json:"armyId"`
+	Troops Troops `json:"troops"`
+}
+
+type ArmyMergeRequest struct {
+	ArmyIds []string `json:"armyIds"`
+}
+
+type ArmyTransferRequest struct {
+	ArmyId    string `json:"armyId"`
+	Recipient string `json:"recipient"`
+	Troops    Troops `json:"troops"`
+}
+
+type CaravanRequest struct {
+	CityId       string `json:"cityId"`
+	TargetCityId string `json:"targetCityId"`
+	Gold         int64  `json:"gold"`
+	Food         int64  `json:"food"`
+}
+
+type UserSettingsRequest struct {
+	DisableAutoMerge bool `json:"disableAutoMerge"`
+}
+
+type CityTaxRequest struct {
+	TaxRate float64 `json:"taxRate"`
+}
+
+type ConstructionRequest struct {
+	CityId         string `json:"cityId"`
+	ConstructionId string `json:"constructionId"`
+	Position       int    `json:"position"`
+}
+
+type TrainingRequest struct {
+	BarracksId string `json:"barracksId"`
+	TrainingId string `json:"trainingId"`
+	TroopType  string `json:"troopType"`
+	Size       int64  `json:"size"`
+	DeployTo   string `json:"deployTo"`
+}
+
+type MarketOrderRequest struct {
+	OrderId string  `json:"orderId"`
+	CityId  string  `json:"cityId"`
+	Side    string  `json:"side"`
+	Price   float64 `json:"price"`
+	Amount  int64   `json:"amount"`
+}
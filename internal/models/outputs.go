@@ -1,8 +1,28 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type WebSocketResponse struct {
-	Msg  int         `json:"msg"`
-	Data interface{} `json:"data"`
+	Id   json.RawMessage `json:"id,omitempty"`  // id of the request being answered, pushed updates have none
+	Seq  uint64          `json:"seq,omitempty"` // position among the updates pushed to the player, replayed after reconnecting
+	Msg  int             `json:"msg"`
+	Data interface{}     `json:"data"`
+}
+
+type WebSocketError struct {
+	Code    string `json:"code"`   // type of the error, e.g. army_not_found
+	Status  int    `json:"status"` // matching http status code
+	Message string `json:"message"`
+}
+
+// ResyncOutput tells a reconnecting client that updates since LastSeq are gone and its state
+// has to be fetched again, updates continue after Seq
+type ResyncOutput struct {
+	LastSeq uint64 `json:"lastSeq"`
+	Seq     uint64 `json:"seq"`
 }
 
 type LoginUserResponse struct {
@@ -20,17 +40,119 @@ type ValidateUserResponse struct {
 	Capital  *City  `json:"capital"`
 }
 
+type SiegeOutput struct {
+	CityId   string    `json:"cityId"`
+	Name     string    `json:"name"`
+	X        int       `json:"x"`
+	Y        int       `json:"y"`
+	Attacker string    `json:"attacker"`
+	Status   string    `json:"status"` // started, captured, failed or lifted
+	End      time.Time `json:"end"`
+}
+
+type ArmyMarchOutput struct {
+	ArmyId      string    `json:"armyId"`
+	TileX       int       `json:"tileX"`
+	TileY       int       `json:"tileY"`
+	ToX         int       `json:"toX"`
+	ToY         int       `json:"toY"`
+	MarchActive bool      `json:"marchActive"`
+	Arrival     time.Time `json:"arrival"`
+}
+
+type CaravanOutput struct {
+	Caravan Army      `json:"caravan"`
+	Arrival time.Time `json:"arrival"`
+}
+
+// CaravanDeliveryOutput is sent to both sides when a caravan is unloaded at its
+// destination or robbed on the way
+type CaravanDeliveryOutput struct {
+	ArmyId    string `json:"armyId"`
+	X         int    `json:"x"`
+	Y         int    `json:"y"`
+	Recipient string `json:"recipient"`
+	Gold      int64  `json:"gold"`
+	Food      int64  `json:"food"`
+	Robbed    bool   `json:"robbed"`
+}
+
+// IncomeOutput sums up the ledger of a player by reason since the given time
+type IncomeOutput struct {
+	Since   time.Time        `json:"since"`
+	Gold    map[string]int64 `json:"gold"`
+	Food    map[string]int64 `json:"food"`
+	NetGold int64            `json:"netGold"`
+	NetFood int64            `json:"netFood"`
+}
+
 type UserAccountOutput struct {
-	Username string   `json:"username"`
-	Gold     int64    `json:"gold"`
-	Food     int64    `json:"food"`
-	Allies   []string `json:"allies"`
+	Username         string   `json:"username"`
+	Gold             int64    `json:"gold"`
+	Food             int64    `json:"food"`
+	GoldCap          int64    `json:"goldCap"`
+	FoodCap          int64    `json:"foodCap"`
+	GoldFill         float64  `json:"goldFill"` // share of the cap in use
+	FoodFill         float64  `json:"foodFill"`
+	Allies           []string `json:"allies"`
+	DisableAutoMerge bool     `json:"disableAutoMerge"`
 }
 
 type MapTileOutput struct {
 	X        int                `json:"x"`
 	Y        int                `json:"y"`
+	Terrain  string             `json:"terrain"`
 	City     *City              `json:"city"`
 	Building *Building          `json:"building"`
 	Armies   map[string][]*Army `json:"armies"`
+	Caravans map[string][]*Army `json:"caravans"`
+}
+
+// MapTileDeltaOutput is pushed to players watching a tile when something on it changes,
+// only the fields of the event are meaningful. City events cover every tile of the city.
+type MapTileDeltaOutput struct {
+	X        int                `json:"x"`
+	Y        int                `json:"y"`
+	Event    string             `json:"event"`
+	ArmyId   string             `json:"armyId"` // army that entered, left or changed
+	Armies   map[string][]*Army `json:"armies"` // everything on the tile after an army event
+	Caravans map[string][]*Army `json:"caravans"`
+	Building *Building          `json:"building"` // nil once demolished
+	City     *City              `json:"city"`
+}
+
+type StarvationOutput struct {
+	ArmyId    string `json:"armyId"`
+	X         int    `json:"x"`
+	Y         int    `json:"y"`
+	Deserted  int64  `json:"deserted"`
+	Remaining int64  `json:"remaining"`
+}
+
+type BattleOutput struct {
+	X              int    `json:"x"`
+	Y              int    `json:"y"`
+	Attacker       string `json:"attacker"`
+	Defender       string `json:"defender"`
+	AttackerLosses int64  `json:"attackerLosses"`
+	DefenderLosses int64  `json:"defenderLosses"`
+}
+
+type BalanceOutput struct {
+	Version int `json:"version"`
+}
+
+type ConstructionOutput struct {
+	Construction
+	Eta time.Time `json:"eta"`
+}
+
+type TrainingOutput struct {
+	Training
+	Eta time.Time `json:"eta"`
+}
+
+type MarketOrderOutput struct {
+	Order  MarketOrder `json:"order"`
+	Trades []Trade     `json:"trades"`
 }
@@ -12,7 +12,11 @@ type User struct {
 	Gold     int64  `json:"gold" gorm:"column:gold;not null;check:gold >= 0"`
 	Food     int64  `json:"food" gorm:"column:food;not null;check:food >= 0"`
 
-	Allies    []string  `json:"allies" gorm:"type:jsonb;serializer:json;not null;default:'[]'"`
+	Allies []string `json:"allies" gorm:"type:jsonb;serializer:json;not null;default:'[]'"`
+
+	// settings
+	DisableAutoMerge bool `json:"disableAutoMerge" gorm:"column:disable_auto_merge;not null;default:false"`
+
 	CreatedAt time.Time `json:"-" gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt time.Time `json:"-" gorm:"column:updated_at;autoUpdateTime"`
 }
@@ -22,6 +26,7 @@ type MapTile struct {
 	Y          int    `json:"y" gorm:"column:y;primaryKey;not null"`
 	CityId     string `json:"cityId" gorm:"column:city_id;size:36;null"`
 	BuildingId string `json:"buildingId" gorm:"column:building_id;size:36;null"`
+	Terrain    string `json:"terrain" gorm:"column:terrain;size:20;not null;default:plains"`
 
 	Armies []Army `json:"-" gorm:"foreignKey:TileX,TileY;references:X,Y"`
 }
@@ -33,6 +38,7 @@ type City struct {
 	Name          string    `json:"name" gorm:"column:name;size:100;not null"`
 	Population    float64   `json:"population" gorm:"column:population;not null;default:0;check:population >= 0"`
 	PopulationCap float64   `json:"populationCap" gorm:"column:population_cap;not null;default:0;check:population_cap >= 0"`
+	TaxRate       float64   `json:"taxRate" gorm:"column:tax_rate;not null;default:0.1;check:tax_rate >= 0"`
 	StartX        int       `json:"startX" gorm:"column:start_x;not null"`
 	StartY        int       `json:"startY" gorm:"column:start_y;not null"`
 	Size          int       `json:"size" gorm:"column:size;not null"`
@@ -47,19 +53,33 @@ type Army struct {
 	TileX  int    `json:"tileX" gorm:"column:tile_x;not null"`
 	TileY  int    `json:"tileY" gorm:"column:tile_y;not null"`
 	Owner  string `json:"owner" gorm:"column:owner;size:36;not null"`
-	Size   int64  `json:"size" gorm:"column:size;not null;check:size > 0"`
+	Kind   string `json:"kind" gorm:"column:kind;size:20;not null;default:army"`
+	Size   int64  `json:"size" gorm:"column:size;not null;check:size >= 0"` // total of all troops, caravans have none
+	Troops Troops `json:"troops" gorm:"type:jsonb;serializer:json;not null;default:'{}'"`
 
 	// march details
-	FromX       int  `json:"fromX" gorm:"column:from_x;null"`
-	FromY       int  `json:"fromY" gorm:"column:from_y;null"`
-	ToX         int  `json:"toX" gorm:"column:to_x;null"`
-	ToY         int  `json:"toY" gorm:"column:to_y;null"`
-	MarchActive bool `json:"marchActive" gorm:"column:march_active;not null;default:false"`
+	FromX       int     `json:"fromX" gorm:"column:from_x;null"`
+	FromY       int     `json:"fromY" gorm:"column:from_y;null"`
+	ToX         int     `json:"toX" gorm:"column:to_x;null"`
+	ToY         int     `json:"toY" gorm:"column:to_y;null"`
+	MarchActive bool    `json:"marchActive" gorm:"column:march_active;not null;default:false"`
+	Route       []Point `json:"route" gorm:"type:jsonb;serializer:json;not null;default:'[]'"` // remaining tiles of the march
+
+	// caravan details
+	CargoGold     int64  `json:"cargoGold" gorm:"column:cargo_gold;not null;default:0"`
+	CargoFood     int64  `json:"cargoFood" gorm:"column:cargo_food;not null;default:0"`
+	Recipient     string `json:"recipient" gorm:"column:recipient;size:36;null"`          // user the cargo is delivered to
+	DestinationId string `json:"destinationId" gorm:"column:destination_id;size:36;null"` // city the cargo is delivered in
 
 	MapTile MapTile `json:"-" gorm:"foreignKey:TileX,TileY;references:X,Y"`
 	User    User    `json:"-" gorm:"foreignKey:Owner;references:UserId"`
 }
 
+type Point struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
 type Building struct {
 	BuildingId      string    `json:"buildingId" gorm:"column:building_id;primaryKey;size:36"`
 	CityId          string    `json:"cityId" gorm:"column:city_id;size:36;not null"`
@@ -67,14 +87,77 @@ type Building struct {
 	Level           int       `json:"level" gorm:"column:level;not null;default:1;check:level >= 0"`
 	X               int       `json:"x" gorm:"column:x;uniqueIndex:compositeindex;not null"`
 	Y               int       `json:"y" gorm:"column:y;uniqueIndex:compositeindex;not null"`
-	ConstructionEnd time.Time `json:"constructionEnd" gorm:"column:construction_end;not null"`
+	ConstructionEnd time.Time `json:"constructionEnd" gorm:"column:construction_end;not null"` // when the current level was finished
+	Housing         float64   `json:"housing" gorm:"column:housing;not null;default:0"`        // population cap the building added to its city
 
 	City City `json:"-"`
 }
 
+// Construction is a building level waiting in or being built from the queue of its city
+type Construction struct {
+	ConstructionId string    `json:"constructionId" gorm:"column:construction_id;primaryKey;size:36"`
+	CityId         string    `json:"cityId" gorm:"column:city_id;size:36;not null;index"`
+	BuildingId     string    `json:"buildingId" gorm:"column:building_id;size:36;not null"`
+	Type           string    `json:"type" gorm:"column:type;size:100;not null"`
+	Level          int       `json:"level" gorm:"column:level;not null;check:level > 0"` // level reached once finished
+	X              int       `json:"x" gorm:"column:x;not null"`
+	Y              int       `json:"y" gorm:"column:y;not null"`
+	Position       int       `json:"position" gorm:"column:position;not null;default:0"`
+	Duration       int64     `json:"duration" gorm:"column:duration;not null"` // in seconds
+	GoldCost       int64     `json:"goldCost" gorm:"column:gold_cost;not null;default:0"`
+	FoodCost       int64     `json:"foodCost" gorm:"column:food_cost;not null;default:0"`
+	Started        bool      `json:"started" gorm:"column:started;not null;default:false"`
+	End            time.Time `json:"end" gorm:"column:end;null"` // only set once started
+}
+
+// Training is a batch of troops waiting in or being trained from the queue of a barracks
 type Training struct {
-	BarracksId string    `json:"barracksId" gorm:"column:barracks_id;primaryKey;size:36"`
+	TrainingId string    `json:"trainingId" gorm:"column:training_id;primaryKey;size:36"`
+	BarracksId string    `json:"barracksId" gorm:"column:barracks_id;size:36;not null;index"`
+	TroopType  string    `json:"troopType" gorm:"column:troop_type;size:20;not null;default:infantry"`
 	Size       int64     `json:"size" gorm:"column:size;not null;check:size > 0"`
 	DeployTo   string    `json:"deployTo" gorm:"column:deploy_to;size:36;null"`
-	End        time.Time `json:"end" gorm:"column:end;not null"`
+	Position   int       `json:"position" gorm:"column:position;not null;default:0"`
+	Duration   int64     `json:"duration" gorm:"column:duration;not null;default:0"` // in seconds
+	GoldCost   int64     `json:"goldCost" gorm:"column:gold_cost;not null;default:0"`
+	FoodCost   int64     `json:"foodCost" gorm:"column:food_cost;not null;default:0"`
+	Started    bool      `json:"started" gorm:"column:started;not null;default:false"`
+	End        time.Time `json:"end" gorm:"column:end;null"` // only set once started
+}
+
+// MarketOrder is an open limit order to buy or sell food for gold. The gold or food
+// it offers is held in escrow until the order is filled or cancelled.
+type MarketOrder struct {
+	OrderId   string    `json:"orderId" gorm:"column:order_id;primaryKey;size:36"`
+	UserId    string    `json:"userId" gorm:"column:user_id;size:36;not null;index"`
+	CityId    string    `json:"cityId" gorm:"column:city_id;size:36;not null"`
+	Side      string    `json:"side" gorm:"column:side;size:4;not null"`
+	Price     float64   `json:"price" gorm:"column:price;not null;check:price > 0"`     // gold per unit of food
+	Amount    int64     `json:"amount" gorm:"column:amount;not null;check:amount >= 0"` // food left to trade
+	Escrow    int64     `json:"escrow" gorm:"column:escrow;not null;default:0"`         // gold or food still held for the order
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at;not null"`
+}
+
+type Trade struct {
+	TradeId     string    `json:"tradeId" gorm:"column:trade_id;primaryKey;size:36"`
+	BuyOrderId  string    `json:"buyOrderId" gorm:"column:buy_order_id;size:36;not null"`
+	SellOrderId string    `json:"sellOrderId" gorm:"column:sell_order_id;size:36;not null"`
+	Buyer       string    `json:"buyer" gorm:"column:buyer;size:36;not null;index"`
+	Seller      string    `json:"seller" gorm:"column:seller;size:36;not null;index"`
+	Price       float64   `json:"price" gorm:"column:price;not null"`
+	Food        int64     `json:"food" gorm:"column:food;not null"`
+	Gold        int64     `json:"gold" gorm:"column:gold;not null"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"column:created_at;not null"`
+}
+
+// LedgerEntry records a single change to the gold or food of a player
+type LedgerEntry struct {
+	EntryId   string    `json:"entryId" gorm:"column:entry_id;primaryKey;size:36"`
+	UserId    string    `json:"userId" gorm:"column:user_id;size:36;not null;index:idx_ledger_user_time"`
+	Resource  string    `json:"resource" gorm:"column:resource;size:20;not null"`
+	Delta     int64     `json:"delta" gorm:"column:delta;not null"`
+	Balance   int64     `json:"balance" gorm:"column:balance;not null"` // amount of the resource left after the change
+	Reason    string    `json:"reason" gorm:"column:reason;size:50;not null"`
+	Source    string    `json:"source" gorm:"column:source;size:36;null"` // id of the entity that caused the change
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at;not null;index:idx_ledger_user_time"`
 }
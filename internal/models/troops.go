@@ -0,0 +1,43 @@
+package models
+
+// Troops is the composition of an army, mapping troop types to their number of units.
+type Troops map[string]int64
+
+func (troops Troops) Total() int64 {
+	var total int64 = 0
+	for _, count := range troops {
+		total += count
+	}
+	return total
+}
+
+// Add returns a new composition holding the units of both.
+func (troops Troops) Add(other Troops) Troops {
+	result := make(Troops, len(troops))
+	for troopType, count := range troops {
+		result[troopType] = count
+	}
+	for troopType, count := range other {
+		result[troopType] += count
+	}
+	return result
+}
+
+// Subtract returns a new composition without the units of other. Returns false if
+// there are not enough units of a type to take away.
+func (troops Troops) Subtract(other Troops) (Troops, bool) {
+	result := make(Troops, len(troops))
+	for troopType, count := range troops {
+		result[troopType] = count
+	}
+	for troopType, count := range other {
+		if count < 0 || result[troopType] < count {
+			return nil, false
+		}
+		result[troopType] -= count
+		if result[troopType] == 0 {
+			delete(result, troopType)
+		}
+	}
+	return result, true
+}
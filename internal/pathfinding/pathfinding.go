@@ -0,0 +1,226 @@
+package pathfinding
+
+import (
+	"cityio/internal/constants"
+	"cityio/internal/messages"
+	"cityio/internal/models"
+
+	"container/heap"
+	"slices"
+	"sync"
+)
+
+// grid mirrors the state of the map tiles that matters for movement,
+// so routes can be planned without querying every tile actor
+type grid struct {
+	mu     sync.RWMutex
+	costs  [constants.MAP_SIZE][constants.MAP_SIZE]int
+	cities [constants.MAP_SIZE][constants.MAP_SIZE]string
+	owners map[string]string // cityId -> owner
+}
+
+var world = newGrid()
+
+func newGrid() *grid {
+	g := &grid{
+		owners: make(map[string]string),
+	}
+	for x := range g.costs {
+		for y := range g.costs[x] {
+			g.costs[x][y] = constants.GetTerrainCost(constants.TERRAIN_PLAINS)
+		}
+	}
+	return g
+}
+
+// Traveller describes who is moving, which decides the cities it may pass through.
+type Traveller struct {
+	Owner  string
+	Allies []string
+}
+
+func InBounds(x int, y int) bool {
+	return x >= 0 && y >= 0 && x < constants.MAP_SIZE && y < constants.MAP_SIZE
+}
+
+func SetTerrain(x int, y int, terrain string) {
+	if !InBounds(x, y) {
+		return
+	}
+	world.mu.Lock()
+	defer world.mu.Unlock()
+	world.costs[x][y] = constants.GetTerrainCost(terrain)
+}
+
+// SetCity registers the tiles covered by a city and its current owner.
+func SetCity(city models.City) {
+	world.mu.Lock()
+	defer world.mu.Unlock()
+	for x := city.StartX; x < city.StartX+city.Size; x++ {
+		for y := city.StartY; y < city.StartY+city.Size; y++ {
+			if InBounds(x, y) {
+				world.cities[x][y] = city.CityId
+			}
+		}
+	}
+	world.owners[city.CityId] = city.Owner
+}
+
+// GetCost returns the number of movement ticks needed to enter a tile, 0 if impassable.
+func GetCost(x int, y int) int {
+	if !InBounds(x, y) {
+		return 0
+	}
+	world.mu.RLock()
+	defer world.mu.RUnlock()
+	return world.costs[x][y]
+}
+
+// IsPassable reports whether the traveller may step from one tile into a neighbouring
+// one on its way to the destination.
+func IsPassable(traveller Traveller, from models.Point, x int, y int, to models.Point) bool {
+	if !InBounds(x, y) || !InBounds(from.X, from.Y) || !InBounds(to.X, to.Y) {
+		return false
+	}
+	world.mu.RLock()
+	defer world.mu.RUnlock()
+	return world.passable(traveller, x, y, world.cities[to.X][to.Y], world.cities[from.X][from.Y])
+}
+
+// RouteCost sums the movement ticks needed to walk a route.
+func RouteCost(route []models.Point) int {
+	world.mu.RLock()
+	defer world.mu.RUnlock()
+	total := 0
+	for _, point := range route {
+		total += world.costs[point.X][point.Y]
+	}
+	return total
+}
+
+// FindPath plans the cheapest route between two tiles with A*. The returned route
+// excludes the starting tile and ends on the destination; it is empty if the two are
+// the same tile. Returns a NoPathError if the destination cannot be reached.
+func FindPath(traveller Traveller, from models.Point, to models.Point) ([]models.Point, error) {
+	if !InBounds(from.X, from.Y) || !InBounds(to.X, to.Y) {
+		return nil, &messages.NoPathError{FromX: from.X, FromY: from.Y, ToX: to.X, ToY: to.Y}
+	}
+	if from == to {
+		return []models.Point{}, nil
+	}
+
+	world.mu.RLock()
+	defer world.mu.RUnlock()
+
+	target := world.cities[to.X][to.Y]
+	origin := world.cities[from.X][from.Y]
+	if !world.passable(traveller, to.X, to.Y, target, origin) {
+		return nil, &messages.NoPathError{FromX: from.X, FromY: from.Y, ToX: to.X, ToY: to.Y}
+	}
+
+	var costs [constants.MAP_SIZE][constants.MAP_SIZE]int
+	var visited [constants.MAP_SIZE][constants.MAP_SIZE]bool
+	var parents [constants.MAP_SIZE][constants.MAP_SIZE]models.Point
+	for x := range costs {
+		for y := range costs[x] {
+			costs[x][y] = -1
+		}
+	}
+	costs[from.X][from.Y] = 0
+
+	open := &queue{}
+	heap.Push(open, &node{point: from, priority: distance(from, to)})
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(*node).point
+		if current == to {
+			break
+		}
+		if visited[current.X][current.Y] {
+			continue
+		}
+		visited[current.X][current.Y] = true
+
+		for _, next := range neighbours(current) {
+			if visited[next.X][next.Y] || !world.passable(traveller, next.X, next.Y, target, origin) {
+				continue
+			}
+			cost := costs[current.X][current.Y] + world.costs[next.X][next.Y]
+			if costs[next.X][next.Y] != -1 && cost >= costs[next.X][next.Y] {
+				continue
+			}
+			costs[next.X][next.Y] = cost
+			parents[next.X][next.Y] = current
+			// every tile costs at least 1, so the manhattan distance never overestimates
+			heap.Push(open, &node{point: next, priority: cost + distance(next, to)})
+		}
+	}
+
+	if costs[to.X][to.Y] == -1 {
+		return nil, &messages.NoPathError{FromX: from.X, FromY: from.Y, ToX: to.X, ToY: to.Y}
+	}
+
+	route := make([]models.Point, 0)
+	for current := to; current != from; current = parents[current.X][current.Y] {
+		route = append(route, current)
+	}
+	slices.Reverse(route)
+	return route, nil
+}
+
+// a tile can be entered if its terrain allows it and it is not part of a city held by
+// someone else, unless that city is the one the traveller is heading to or the one it
+// is leaving, e.g. after the city was captured around it
+func (g *grid) passable(traveller Traveller, x int, y int, target string, origin string) bool {
+	if g.costs[x][y] == 0 {
+		return false
+	}
+	cityId := g.cities[x][y]
+	if cityId == "" || cityId == target || cityId == origin {
+		return true
+	}
+	owner := g.owners[cityId]
+	return owner == traveller.Owner || slices.Contains(traveller.Allies, owner)
+}
+
+func neighbours(point models.Point) []models.Point {
+	points := make([]models.Point, 0, 4)
+	for _, delta := range []models.Point{{X: 1, Y: 0}, {X: -1, Y: 0}, {X: 0, Y: 1}, {X: 0, Y: -1}} {
+		next := models.Point{X: point.X + delta.X, Y: point.Y + delta.Y}
+		if InBounds(next.X, next.Y) {
+			points = append(points, next)
+		}
+	}
+	return points
+}
+
+func distance(a models.Point, b models.Point) int {
+	return abs(a.X-b.X) + abs(a.Y-b.Y)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+type node struct {
+	point    models.Point
+	priority int
+}
+
+// priority queue of nodes ordered by estimated total cost
+type queue []*node
+
+func (q queue) Len() int           { return len(q) }
+func (q queue) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q queue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *queue) Push(x any)        { *q = append(*q, x.(*node)) }
+func (q *queue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
@@ -0,0 +1,188 @@
+package pathfinding
+
+import (
+	"cityio/internal/constants"
+	"cityio/internal/messages"
+	"cityio/internal/models"
+
+	"errors"
+	"testing"
+)
+
+func TestFindPath(t *testing.T) {
+	player := Traveller{Owner: "player"}
+	wall := func(x int, fromY int, toY int) func() {
+		return func() {
+			for y := fromY; y <= toY; y++ {
+				SetTerrain(x, y, constants.TERRAIN_MOUNTAINS)
+			}
+		}
+	}
+
+	tests := []struct {
+		name      string
+		setup     func()
+		traveller Traveller
+		from      models.Point
+		to        models.Point
+		cost      int // expected route cost, -1 when no route exists
+	}{
+		{
+			name:      "straight line over plains",
+			traveller: player,
+			from:      models.Point{X: 0, Y: 0},
+			to:        models.Point{X: 3, Y: 0},
+			cost:      3,
+		},
+		{
+			name:      "same tile",
+			traveller: player,
+			from:      models.Point{X: 5, Y: 5},
+			to:        models.Point{X: 5, Y: 5},
+			cost:      0,
+		},
+		{
+			name:      "destination out of bounds",
+			traveller: player,
+			from:      models.Point{X: 0, Y: 0},
+			to:        models.Point{X: constants.MAP_SIZE, Y: 0},
+			cost:      -1,
+		},
+		{
+			name:      "destination is impassable",
+			setup:     func() { SetTerrain(3, 0, constants.TERRAIN_MOUNTAINS) },
+			traveller: player,
+			from:      models.Point{X: 0, Y: 0},
+			to:        models.Point{X: 3, Y: 0},
+			cost:      -1,
+		},
+		{
+			name:      "crosses a forest instead of walking around it",
+			setup:     func() { SetTerrain(2, 0, constants.TERRAIN_FOREST) },
+			traveller: player,
+			from:      models.Point{X: 0, Y: 0},
+			to:        models.Point{X: 4, Y: 0},
+			cost:      5,
+		},
+		{
+			name: "walks around hills when it is cheaper",
+			setup: func() {
+				SetTerrain(2, 0, constants.TERRAIN_HILLS)
+				SetTerrain(3, 0, constants.TERRAIN_HILLS)
+			},
+			traveller: player,
+			from:      models.Point{X: 0, Y: 0},
+			to:        models.Point{X: 4, Y: 0},
+			cost:      6,
+		},
+		{
+			name: "crosses hills when the detour is longer",
+			setup: func() {
+				SetTerrain(2, 0, constants.TERRAIN_HILLS)
+				wall(2, 1, 5)()
+			},
+			traveller: player,
+			from:      models.Point{X: 0, Y: 0},
+			to:        models.Point{X: 4, Y: 0},
+			cost:      6,
+		},
+		{
+			name:      "goes through the gap of a mountain range",
+			setup:     wall(2, 0, 9),
+			traveller: player,
+			from:      models.Point{X: 0, Y: 0},
+			to:        models.Point{X: 4, Y: 0},
+			cost:      24,
+		},
+		{
+			name: "enclosed destination",
+			setup: func() {
+				for _, point := range neighbours(models.Point{X: 10, Y: 10}) {
+					SetTerrain(point.X, point.Y, constants.TERRAIN_MOUNTAINS)
+				}
+			},
+			traveller: player,
+			from:      models.Point{X: 0, Y: 0},
+			to:        models.Point{X: 10, Y: 10},
+			cost:      -1,
+		},
+		{
+			name:      "walks around a foreign city",
+			setup:     func() { SetCity(models.City{CityId: "foreign", Owner: "rival", StartX: 2, StartY: 0, Size: 2}) },
+			traveller: player,
+			from:      models.Point{X: 0, Y: 0},
+			to:        models.Point{X: 5, Y: 0},
+			cost:      9,
+		},
+		{
+			name:      "passes through an own city",
+			setup:     func() { SetCity(models.City{CityId: "own", Owner: "player", StartX: 2, StartY: 0, Size: 2}) },
+			traveller: player,
+			from:      models.Point{X: 0, Y: 0},
+			to:        models.Point{X: 5, Y: 0},
+			cost:      5,
+		},
+		{
+			name:      "passes through an allied city",
+			setup:     func() { SetCity(models.City{CityId: "allied", Owner: "ally", StartX: 2, StartY: 0, Size: 2}) },
+			traveller: Traveller{Owner: "player", Allies: []string{"ally"}},
+			from:      models.Point{X: 0, Y: 0},
+			to:        models.Point{X: 5, Y: 0},
+			cost:      5,
+		},
+		{
+			name:      "enters the foreign city it is heading to",
+			setup:     func() { SetCity(models.City{CityId: "target", Owner: "rival", StartX: 2, StartY: 0, Size: 3}) },
+			traveller: player,
+			from:      models.Point{X: 0, Y: 0},
+			to:        models.Point{X: 3, Y: 1},
+			cost:      4,
+		},
+		{
+			name:      "leaves a city captured around it",
+			setup:     func() { SetCity(models.City{CityId: "captured", Owner: "rival", StartX: 0, StartY: 0, Size: 3}) },
+			traveller: player,
+			from:      models.Point{X: 1, Y: 1},
+			to:        models.Point{X: 5, Y: 1},
+			cost:      4,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world = newGrid()
+			if test.setup != nil {
+				test.setup()
+			}
+
+			route, err := FindPath(test.traveller, test.from, test.to)
+			if test.cost == -1 {
+				var noPath *messages.NoPathError
+				if !errors.As(err, &noPath) {
+					t.Fatalf("expected a NoPathError, got route %v and error %v", route, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			previous := test.from
+			for _, point := range route {
+				if distance(previous, point) != 1 {
+					t.Fatalf("route %v jumps from %v to %v", route, previous, point)
+				}
+				if !IsPassable(test.traveller, test.from, point.X, point.Y, test.to) {
+					t.Fatalf("route %v crosses impassable tile %v", route, point)
+				}
+				previous = point
+			}
+			if previous != test.to {
+				t.Fatalf("route %v ends at %v instead of %v", route, previous, test.to)
+			}
+			if cost := RouteCost(route); cost != test.cost {
+				t.Errorf("route %v costs %d, expected %d", route, cost, test.cost)
+			}
+		})
+	}
+}
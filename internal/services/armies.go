@@ -7,7 +7,9 @@ import (
 	"cityio/internal/models"
 
 	"log"
+	"time"
 
+	"github.com/asynkron/protoactor-go/actor"
 	"github.com/google/uuid"
 )
 
@@ -89,6 +91,9 @@ func CreateArmy(army models.Army) (string, error) {
 	}
 
 	army.ArmyId = uuid.New().String()
+	if len(army.Troops) > 0 {
+		army.Size = army.Troops.Total()
+	}
 	createArmyResponse, err := actors.Request[messages.CreateArmyResponseMessage](system.Root, armyPID, messages.CreateArmyMessage{
 		Army:    army,
 		Restore: false,
@@ -153,6 +158,189 @@ func GetArmy(armyId string) (models.Army, error) {
 	return getArmyResponse.Army, nil
 }
 
+func MarchArmy(armyId string, x int, y int) (time.Time, error) {
+	getArmyPIDResponse, err := actors.Request[messages.GetArmyPIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetArmyPIDMessage{
+		ArmyId: armyId,
+	})
+	if err != nil {
+		log.Printf("Error marching army: %s", err)
+		return time.Time{}, err
+	}
+	if getArmyPIDResponse.PID == nil {
+		return time.Time{}, &messages.ArmyNotFoundError{ArmyId: armyId}
+	}
+
+	marchResponse, err := actors.Request[messages.StartArmyMarchResponseMessage](system.Root, getArmyPIDResponse.PID, messages.StartArmyMarchMessage{
+		X: x,
+		Y: y,
+	})
+	if err != nil {
+		log.Printf("Error marching army: %s", err)
+		return time.Time{}, err
+	}
+	if marchResponse.Error != nil {
+		log.Printf("Error marching army: %s", marchResponse.Error)
+		return time.Time{}, marchResponse.Error
+	}
+
+	return marchResponse.Arrival, nil
+}
+
+func RedirectArmy(armyId string, x int, y int) (time.Time, error) {
+	getArmyPIDResponse, err := actors.Request[messages.GetArmyPIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetArmyPIDMessage{
+		ArmyId: armyId,
+	})
+	if err != nil {
+		log.Printf("Error redirecting army: %s", err)
+		return time.Time{}, err
+	}
+	if getArmyPIDResponse.PID == nil {
+		return time.Time{}, &messages.ArmyNotFoundError{ArmyId: armyId}
+	}
+
+	redirectResponse, err := actors.Request[messages.RedirectArmyMarchResponseMessage](system.Root, getArmyPIDResponse.PID, messages.RedirectArmyMarchMessage{
+		X: x,
+		Y: y,
+	})
+	if err != nil {
+		log.Printf("Error redirecting army: %s", err)
+		return time.Time{}, err
+	}
+	if redirectResponse.Error != nil {
+		log.Printf("Error redirecting army: %s", redirectResponse.Error)
+		return time.Time{}, redirectResponse.Error
+	}
+
+	return redirectResponse.Arrival, nil
+}
+
+func CancelArmyMarch(armyId string) error {
+	getArmyPIDResponse, err := actors.Request[messages.GetArmyPIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetArmyPIDMessage{
+		ArmyId: armyId,
+	})
+	if err != nil {
+		log.Printf("Error cancelling army march: %s", err)
+		return err
+	}
+	if getArmyPIDResponse.PID == nil {
+		return &messages.ArmyNotFoundError{ArmyId: armyId}
+	}
+
+	cancelResponse, err := actors.Request[messages.CancelArmyMarchResponseMessage](system.Root, getArmyPIDResponse.PID, messages.CancelArmyMarchMessage{})
+	if err != nil {
+		log.Printf("Error cancelling army march: %s", err)
+		return err
+	}
+	if cancelResponse.Error != nil {
+		log.Printf("Error cancelling army march: %s", cancelResponse.Error)
+		return cancelResponse.Error
+	}
+
+	return nil
+}
+
+func SplitArmy(armyId string, troops models.Troops) (string, error) {
+	army, err := GetArmy(armyId)
+	if err != nil {
+		return "", err
+	}
+	tilePID, err := getArmyTilePID(army)
+	if err != nil {
+		log.Printf("Error splitting army: %s", err)
+		return "", err
+	}
+
+	splitResponse, err := actors.Request[messages.SplitTileArmyResponseMessage](system.Root, tilePID, messages.SplitTileArmyMessage{
+		Owner:  army.Owner,
+		ArmyId: armyId,
+		Troops: troops,
+	})
+	if err != nil {
+		log.Printf("Error splitting army: %s", err)
+		return "", err
+	}
+	if splitResponse.Error != nil {
+		log.Printf("Error splitting army: %s", splitResponse.Error)
+		return "", splitResponse.Error
+	}
+
+	return splitResponse.ArmyId, nil
+}
+
+// MergeArmies joins armies on the same tile into the first of them, returning its id.
+func MergeArmies(armyIds []string) (string, error) {
+	if len(armyIds) < 2 {
+		return "", &messages.TooFewArmiesError{Count: len(armyIds)}
+	}
+	army, err := GetArmy(armyIds[0])
+	if err != nil {
+		return "", err
+	}
+	tilePID, err := getArmyTilePID(army)
+	if err != nil {
+		log.Printf("Error merging armies: %s", err)
+		return "", err
+	}
+
+	mergeResponse, err := actors.Request[messages.MergeTileArmiesResponseMessage](system.Root, tilePID, messages.MergeTileArmiesMessage{
+		Owner:   army.Owner,
+		ArmyIds: armyIds,
+	})
+	if err != nil {
+		log.Printf("Error merging armies: %s", err)
+		return "", err
+	}
+	if mergeResponse.Error != nil {
+		log.Printf("Error merging armies: %s", mergeResponse.Error)
+		return "", mergeResponse.Error
+	}
+
+	return mergeResponse.ArmyId, nil
+}
+
+func TransferTroops(armyId string, recipient string, troops models.Troops) error {
+	army, err := GetArmy(armyId)
+	if err != nil {
+		return err
+	}
+	tilePID, err := getArmyTilePID(army)
+	if err != nil {
+		log.Printf("Error transferring troops: %s", err)
+		return err
+	}
+
+	transferResponse, err := actors.Request[messages.TransferTileArmyResponseMessage](system.Root, tilePID, messages.TransferTileArmyMessage{
+		Owner:     army.Owner,
+		ArmyId:    armyId,
+		Recipient: recipient,
+		Troops:    troops,
+	})
+	if err != nil {
+		log.Printf("Error transferring troops: %s", err)
+		return err
+	}
+	if transferResponse.Error != nil {
+		log.Printf("Error transferring troops: %s", transferResponse.Error)
+		return transferResponse.Error
+	}
+
+	return nil
+}
+
+func getArmyTilePID(army models.Army) (*actor.PID, error) {
+	getMapTilePIDResponse, err := actors.Request[messages.GetMapTilePIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetMapTilePIDMessage{
+		X: army.TileX,
+		Y: army.TileY,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if getMapTilePIDResponse.PID == nil {
+		return nil, &messages.MapTileNotFoundError{X: army.TileX, Y: army.TileY}
+	}
+	return getMapTilePIDResponse.PID, nil
+}
+
 func DeleteUserArmies(userId string) error {
 	db := database.GetDb()
 
@@ -6,37 +6,15 @@ import (
 	"cityio/internal/messages"
 	"cityio/internal/models"
 
-	"errors"
 	"log"
 	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
 	"github.com/google/uuid"
-	"gorm.io/gorm"
 )
 
 func RestoreBuilding(building models.Building) error {
-	var err error
-	var buildingPID *actor.PID
-	switch building.Type {
-	case constants.BUILDING_TYPE_CITY_CENTER:
-		buildingPID, err = actors.Spawn(&actors.CityCenterActor{})
-	case constants.BUILDING_TYPE_TOWN_CENTER:
-		buildingPID, err = actors.Spawn(&actors.TownCenterActor{})
-	case constants.BUILDING_TYPE_BARRACKS:
-		buildingPID, err = actors.Spawn(&actors.BarracksActor{})
-	case constants.BUILDING_TYPE_HOUSE:
-		buildingPID, err = actors.Spawn(&actors.HouseActor{})
-	case constants.BUILDING_TYPE_FARM:
-		buildingPID, err = actors.Spawn(&actors.FarmActor{})
-	case constants.BUILDING_TYPE_MINE:
-		buildingPID, err = actors.Spawn(&actors.MineActor{})
-	default:
-		return &messages.BuildingTypeNotFoundError{
-			BuildingType: building.Type,
-		}
-	}
-
+	buildingPID, err := actors.SpawnBuilding(building.Type)
 	if err != nil {
 		log.Printf("Error spawning building actor: %s", err)
 		return err
@@ -97,20 +75,25 @@ func RestoreBuilding(building models.Building) error {
 		return addBuildingResponse.Error
 	}
 
+	err = addCityBuilding(building, true)
+	if err != nil {
+		return err
+	}
+
 	if building.Type == constants.BUILDING_TYPE_BARRACKS {
-		var training models.Training
-		result := db.Where("barracks_id = ?", building.BuildingId).First(&training)
+		var trainings []models.Training
+		result := db.Where("barracks_id = ?", building.BuildingId).Order("position").Find(&trainings)
 		if result.Error != nil {
-			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-				return nil
-			}
-			log.Printf("Error getting training: %s", result.Error)
+			log.Printf("Error getting trainings: %s", result.Error)
 			return result.Error
 		}
+		if len(trainings) == 0 {
+			return nil
+		}
 
 		var restoreTrainingResponse *messages.RestoreTrainingResponseMessage
 		restoreTrainingResponse, err = actors.Request[messages.RestoreTrainingResponseMessage](system.Root, buildingPID, messages.RestoreTrainingMessage{
-			Training: training,
+			Trainings: trainings,
 		})
 		if err != nil {
 			log.Printf("Error restoring training: %s", err)
@@ -126,34 +109,104 @@ func RestoreBuilding(building models.Building) error {
 }
 
 func ConstructBuilding(building models.Building) (string, error) {
-	var err error
-	var buildingPID *actor.PID
-	switch building.Type {
-	case constants.BUILDING_TYPE_CITY_CENTER:
-		buildingPID, err = actors.Spawn(&actors.CityCenterActor{})
-	case constants.BUILDING_TYPE_TOWN_CENTER:
-		buildingPID, err = actors.Spawn(&actors.TownCenterActor{})
-	case constants.BUILDING_TYPE_BARRACKS:
-		buildingPID, err = actors.Spawn(&actors.BarracksActor{})
-	case constants.BUILDING_TYPE_HOUSE:
-		buildingPID, err = actors.Spawn(&actors.HouseActor{})
-	case constants.BUILDING_TYPE_FARM:
-		buildingPID, err = actors.Spawn(&actors.FarmActor{})
-	case constants.BUILDING_TYPE_MINE:
-		buildingPID, err = actors.Spawn(&actors.MineActor{})
-	default:
+	if !constants.IsBuildingType(building.Type) {
 		return "", &messages.BuildingTypeNotFoundError{
 			BuildingType: building.Type,
 		}
 	}
 
+	city, err := GetCity(building.CityId)
 	if err != nil {
-		log.Printf("Error spawning building actor: %s", err)
+		log.Printf("Error constructing building: %s", err)
+		return "", err
+	}
+
+	err = validateBuildingPlacement(building, city)
+	if err != nil {
+		log.Printf("Error constructing building: %s", err)
 		return "", err
 	}
 
+	// the building stands at level 0 until its first level is finished by the queue
+	building.Level = 0
+	buildingId, err := spawnBuilding(building)
+	if err != nil {
+		return "", err
+	}
+	building.BuildingId = buildingId
+
+	_, err = queueConstruction(building)
+	if err != nil {
+		removeErr := removeBuilding(building)
+		if removeErr != nil {
+			log.Printf("Error removing unpaid building: %s", removeErr)
+			return "", removeErr
+		}
+		return "", err
+	}
+	return buildingId, nil
+}
+
+func spawnBuilding(building models.Building) (string, error) {
 	building.BuildingId = uuid.New().String()
-	building.ConstructionEnd = time.Now().Add(time.Duration(constants.GetBuildingConstructionTime(building.Type, 1)) * time.Second)
+	building.ConstructionEnd = time.Now()
+
+	getMapTilePIDResponse, err := actors.Request[messages.GetMapTilePIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetMapTilePIDMessage{
+		X: building.X,
+		Y: building.Y,
+	})
+	if err != nil {
+		log.Printf("Error getting map tile pid: %s", err)
+		return "", err
+	}
+	if getMapTilePIDResponse.PID == nil {
+		log.Printf("Error getting map tile pid: %s", &messages.MapTileNotFoundError{X: building.X, Y: building.Y})
+		return "", &messages.MapTileNotFoundError{X: building.X, Y: building.Y}
+	}
+	tilePID := getMapTilePIDResponse.PID
+
+	// reserve the tile before spawning so concurrent constructions cannot claim it twice
+	addBuildingResponse, err := actors.Request[messages.AddBuildingToTileResponseMessage](system.Root, tilePID, messages.AddBuildingToTileMessage{
+		BuildingId: building.BuildingId,
+	})
+	if err != nil {
+		log.Printf("Error adding building to tile: %s", err)
+		return "", err
+	}
+	if addBuildingResponse.Error != nil {
+		log.Printf("Error adding building to tile: %s", addBuildingResponse.Error)
+		return "", addBuildingResponse.Error
+	}
+
+	err = addCityBuilding(building, false)
+	if err != nil {
+		system.Root.Send(tilePID, messages.RemoveBuildingFromTileMessage{
+			BuildingId: building.BuildingId,
+		})
+		return "", err
+	}
+
+	buildingId, err := createBuilding(building)
+	if err != nil {
+		system.Root.Send(tilePID, messages.RemoveBuildingFromTileMessage{
+			BuildingId: building.BuildingId,
+		})
+		removeErr := removeCityBuilding(building)
+		if removeErr != nil {
+			log.Printf("Error releasing city slot of building: %s", removeErr)
+		}
+		return "", err
+	}
+	return buildingId, nil
+}
+
+func createBuilding(building models.Building) (string, error) {
+	buildingPID, err := actors.SpawnBuilding(building.Type)
+	if err != nil {
+		log.Printf("Error spawning building actor: %s", err)
+		return "", err
+	}
+
 	var createBuildingResponse *messages.CreateBuildingResponseMessage
 	createBuildingResponse, err = actors.Request[messages.CreateBuildingResponseMessage](system.Root, buildingPID, messages.CreateBuildingMessage{
 		Building: building,
@@ -182,88 +235,290 @@ func ConstructBuilding(building models.Building) (string, error) {
 		return "", addBuildingPIDResponse.Error
 	}
 
-	var getMapTilePIDResponse *messages.GetMapTilePIDResponseMessage
-	getMapTilePIDResponse, err = actors.Request[messages.GetMapTilePIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetMapTilePIDMessage{
+	return building.BuildingId, nil
+}
+
+func addCityBuilding(building models.Building, restore bool) error {
+	getCityPIDResponse, err := actors.Request[messages.GetCityPIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetCityPIDMessage{
+		CityId: building.CityId,
+	})
+	if err != nil {
+		log.Printf("Error adding building to city: %s", err)
+		return err
+	}
+	if getCityPIDResponse.PID == nil {
+		return &messages.CityNotFoundError{CityId: building.CityId}
+	}
+
+	addCityBuildingResponse, err := actors.Request[messages.AddCityBuildingResponseMessage](system.Root, getCityPIDResponse.PID, messages.AddCityBuildingMessage{
+		BuildingId:   building.BuildingId,
+		BuildingType: building.Type,
+		X:            building.X,
+		Y:            building.Y,
+		Restore:      restore,
+	})
+	if err != nil {
+		log.Printf("Error adding building to city: %s", err)
+		return err
+	}
+	if addCityBuildingResponse.Error != nil {
+		log.Printf("Error adding building to city: %s", addCityBuildingResponse.Error)
+		return addCityBuildingResponse.Error
+	}
+	return nil
+}
+
+func removeCityBuilding(building models.Building) error {
+	getCityPIDResponse, err := actors.Request[messages.GetCityPIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetCityPIDMessage{
+		CityId: building.CityId,
+	})
+	if err != nil {
+		return err
+	}
+	if getCityPIDResponse.PID == nil {
+		return nil
+	}
+
+	removeCityBuildingResponse, err := actors.Request[messages.RemoveCityBuildingResponseMessage](system.Root, getCityPIDResponse.PID, messages.RemoveCityBuildingMessage{
+		BuildingId: building.BuildingId,
+	})
+	if err != nil {
+		return err
+	}
+	return removeCityBuildingResponse.Error
+}
+
+// checks that a building can be placed in a city before anything is spawned for it
+func validateBuildingPlacement(building models.Building, city models.City) error {
+	if building.X < 0 || building.Y < 0 || building.X >= constants.MAP_SIZE || building.Y >= constants.MAP_SIZE {
+		return &messages.InvalidCoordinatesError{X: building.X, Y: building.Y}
+	}
+	if building.X < city.StartX || building.Y < city.StartY || building.X >= city.StartX+city.Size || building.Y >= city.StartY+city.Size {
+		return &messages.TileOutsideCityError{CityId: city.CityId, X: building.X, Y: building.Y}
+	}
+	if !constants.IsBuildingAllowed(building.Type, city.Type) {
+		return &messages.BuildingTypeNotAllowedError{BuildingType: building.Type, CityType: city.Type}
+	}
+
+	getMapTilePIDResponse, err := actors.Request[messages.GetMapTilePIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetMapTilePIDMessage{
 		X: building.X,
 		Y: building.Y,
 	})
 	if err != nil {
-		log.Printf("Error getting map tile pid: %s", err)
-		return "", err
+		return err
 	}
 	if getMapTilePIDResponse.PID == nil {
-		log.Printf("Error getting map tile pid: %s", &messages.MapTileNotFoundError{X: building.X, Y: building.Y})
-		return "", err
+		return &messages.MapTileNotFoundError{X: building.X, Y: building.Y}
 	}
 
-	var addBuildingResponse *messages.AddBuildingToTileResponseMessage
-	addBuildingResponse, err = actors.Request[messages.AddBuildingToTileResponseMessage](system.Root, getMapTilePIDResponse.PID, messages.AddBuildingToTileMessage{
-		BuildingId: building.BuildingId,
+	getMapTileResponse, err := actors.Request[messages.GetMapTileResponseMessage](system.Root, getMapTilePIDResponse.PID, messages.GetMapTileMessage{})
+	if err != nil {
+		return err
+	}
+	if getMapTileResponse.Tile.CityId != city.CityId {
+		return &messages.TileOutsideCityError{CityId: city.CityId, X: building.X, Y: building.Y}
+	}
+	if getMapTileResponse.Tile.BuildingId != "" {
+		return &messages.TileOccupiedError{X: building.X, Y: building.Y, BuildingId: getMapTileResponse.Tile.BuildingId}
+	}
+
+	// the building limit is enforced by the city once the building claims its tile there
+	return nil
+}
+
+func UpgradeBuilding(building models.Building) (models.Construction, error) {
+	construction, err := queueConstruction(building)
+	if err != nil {
+		log.Printf("Error upgrading building: %s", err)
+		return models.Construction{}, err
+	}
+
+	return construction, nil
+}
+
+func GetBuilding(buildingId string) (models.Building, error) {
+	getBuildingPIDResponse, err := actors.Request[messages.GetBuildingPIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetBuildingPIDMessage{
+		BuildingId: buildingId,
 	})
 	if err != nil {
-		log.Printf("Error adding building to tile: %s", err)
-		return "", err
+		log.Printf("Error getting building: %s", err)
+		return models.Building{}, err
 	}
-	if addBuildingResponse.Error != nil {
-		log.Printf("Error adding building to tile: %s", addBuildingResponse.Error)
-		return "", addBuildingResponse.Error
+	if getBuildingPIDResponse.PID == nil {
+		return models.Building{}, &messages.BuildingNotFoundError{BuildingId: buildingId}
 	}
 
-	return building.BuildingId, nil
+	getBuildingResponse, err := actors.Request[messages.GetBuildingResponseMessage](system.Root, getBuildingPIDResponse.PID, messages.GetBuildingMessage{})
+	if err != nil {
+		log.Printf("Error getting building: %s", err)
+		return models.Building{}, err
+	}
+
+	return getBuildingResponse.Building, nil
 }
 
-func UpgradeBuilding(building models.Building) error {
+func DemolishBuilding(buildingId string) error {
+	building, err := GetBuilding(buildingId)
+	if err != nil {
+		log.Printf("Error demolishing building: %s", err)
+		return err
+	}
+	// a city cannot function without its center
+	if building.Type == constants.BUILDING_TYPE_CITY_CENTER || building.Type == constants.BUILDING_TYPE_TOWN_CENTER {
+		return &messages.BuildingNotDemolishableError{BuildingId: buildingId, BuildingType: building.Type}
+	}
+
+	return removeBuilding(building)
+}
+
+// tears a building down and frees its tile and city slot, without the checks players
+// are held to, so a building that was never paid for can always be rolled back
+func removeBuilding(building models.Building) error {
+	buildingId := building.BuildingId
 	getBuildingPIDResponse, err := actors.Request[messages.GetBuildingPIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetBuildingPIDMessage{
-		BuildingId: building.BuildingId,
+		BuildingId: buildingId,
 	})
 	if err != nil {
-		log.Printf("Error upgrading building: %s", err)
+		log.Printf("Error demolishing building: %s", err)
 		return err
 	}
 	if getBuildingPIDResponse.PID == nil {
-		log.Printf("Error upgrading building: %s", &messages.BuildingNotFoundError{BuildingId: building.BuildingId})
-		return err
+		return &messages.BuildingNotFoundError{BuildingId: buildingId}
 	}
 
-	var upgradeResponse *messages.UpgradeBuildingResponseMessage
-	upgradeResponse, err = actors.Request[messages.UpgradeBuildingResponseMessage](system.Root, getBuildingPIDResponse.PID, messages.UpgradeBuildingMessage{})
+	deleteBuildingResponse, err := actors.Request[messages.DeleteBuildingResponseMessage](system.Root, getBuildingPIDResponse.PID, messages.DeleteBuildingMessage{
+		BuildingId: buildingId,
+	})
 	if err != nil {
-		log.Printf("Error upgrading building: %s", err)
+		log.Printf("Error demolishing building: %s", err)
 		return err
 	}
-	if upgradeResponse.Error != nil {
-		log.Printf("Error upgrading building: %s", upgradeResponse.Error)
-		return upgradeResponse.Error
+	if deleteBuildingResponse.Error != nil {
+		log.Printf("Error demolishing building: %s", deleteBuildingResponse.Error)
+		return deleteBuildingResponse.Error
 	}
 
-	return nil
-}
+	deleteBuildingPIDResponse, err := actors.Request[messages.DeleteBuildingPIDResponseMessage](system.Root, actors.GetManagerPID(), messages.DeleteBuildingPIDMessage{
+		BuildingId: buildingId,
+	})
+	if err != nil {
+		log.Printf("Error demolishing building: %s", err)
+		return err
+	}
+	if deleteBuildingPIDResponse.Error != nil {
+		log.Printf("Error demolishing building: %s", deleteBuildingPIDResponse.Error)
+		return deleteBuildingPIDResponse.Error
+	}
 
-func TrainTroops(training models.Training) error {
-	getBarracksPIDResponse, err := actors.Request[messages.GetBuildingPIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetBuildingPIDMessage{
-		BuildingId: training.BarracksId,
+	getMapTilePIDResponse, err := actors.Request[messages.GetMapTilePIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetMapTilePIDMessage{
+		X: building.X,
+		Y: building.Y,
 	})
 	if err != nil {
-		log.Printf("Error training troops: %s", err)
+		log.Printf("Error demolishing building: %s", err)
 		return err
 	}
-	if getBarracksPIDResponse.PID == nil {
-		log.Printf("Error training troops: %s", &messages.BuildingNotFoundError{BuildingId: training.BarracksId})
+	if getMapTilePIDResponse.PID != nil {
+		removeBuildingResponse, err := actors.Request[messages.RemoveBuildingFromTileResponseMessage](system.Root, getMapTilePIDResponse.PID, messages.RemoveBuildingFromTileMessage{
+			BuildingId: buildingId,
+		})
+		if err != nil {
+			log.Printf("Error demolishing building: %s", err)
+			return err
+		}
+		if removeBuildingResponse.Error != nil {
+			log.Printf("Error demolishing building: %s", removeBuildingResponse.Error)
+			return removeBuildingResponse.Error
+		}
+	}
+
+	err = removeCityBuilding(building)
+	if err != nil {
+		log.Printf("Error demolishing building: %s", err)
 		return err
 	}
 
-	var trainResponse *messages.TrainTroopsResponseMessage
-	trainResponse, err = actors.Request[messages.TrainTroopsResponseMessage](system.Root, getBarracksPIDResponse.PID, messages.TrainTroopsMessage{
+	return nil
+}
+
+func TrainTroops(training models.Training) (models.Training, error) {
+	barracksPID, err := getBarracksPID(training.BarracksId)
+	if err != nil {
+		log.Printf("Error training troops: %s", err)
+		return models.Training{}, err
+	}
+
+	trainResponse, err := actors.Request[messages.TrainTroopsResponseMessage](system.Root, barracksPID, messages.TrainTroopsMessage{
 		Training: training,
 	})
 	if err != nil {
 		log.Printf("Error training troops: %s", err)
-		return err
+		return models.Training{}, err
 	}
 	if trainResponse.Error != nil {
 		log.Printf("Error training troops: %s", trainResponse.Error)
-		return trainResponse.Error
+		return models.Training{}, trainResponse.Error
+	}
+
+	return trainResponse.Training, nil
+}
+
+func GetTrainings(barracksId string) ([]models.TrainingOutput, error) {
+	barracksPID, err := getBarracksPID(barracksId)
+	if err != nil {
+		log.Printf("Error getting trainings: %s", err)
+		return nil, err
+	}
+
+	getTrainingsResponse, err := actors.Request[messages.GetTrainingsResponseMessage](system.Root, barracksPID, messages.GetTrainingsMessage{})
+	if err != nil {
+		log.Printf("Error getting trainings: %s", err)
+		return nil, err
+	}
+
+	return getTrainingsResponse.Trainings, nil
+}
+
+func CancelTraining(barracksId string, trainingId string) error {
+	barracksPID, err := getBarracksPID(barracksId)
+	if err != nil {
+		log.Printf("Error cancelling training: %s", err)
+		return err
+	}
+
+	cancelResponse, err := actors.Request[messages.CancelTrainingResponseMessage](system.Root, barracksPID, messages.CancelTrainingMessage{
+		TrainingId: trainingId,
+	})
+	if err != nil {
+		log.Printf("Error cancelling training: %s", err)
+		return err
+	}
+	if cancelResponse.Error != nil {
+		log.Printf("Error cancelling training: %s", cancelResponse.Error)
+		return cancelResponse.Error
 	}
 
 	return nil
 }
+
+// looks up the actor of a building, making sure it is a barracks that handles trainings
+func getBarracksPID(barracksId string) (*actor.PID, error) {
+	building, err := GetBuilding(barracksId)
+	if err != nil {
+		return nil, err
+	}
+	if building.Type != constants.BUILDING_TYPE_BARRACKS {
+		return nil, &messages.NotBarracksError{BuildingId: barracksId}
+	}
+
+	getBarracksPIDResponse, err := actors.Request[messages.GetBuildingPIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetBuildingPIDMessage{
+		BuildingId: barracksId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if getBarracksPIDResponse.PID == nil {
+		return nil, &messages.BuildingNotFoundError{BuildingId: barracksId}
+	}
+	return getBarracksPIDResponse.PID, nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"cityio/internal/actors"
+	"cityio/internal/constants"
+	"cityio/internal/messages"
+	"cityio/internal/models"
+	"cityio/internal/pathfinding"
+
+	"log"
+	"slices"
+)
+
+// SendCaravan loads resources of a player onto a caravan that marches from one of
+// their cities to another, or to a city of an ally. The cargo leaves the account of
+// the player right away and only reaches the recipient if the caravan gets through.
+func SendCaravan(userId string, input models.CaravanRequest) (models.CaravanOutput, error) {
+	if input.Gold < 0 || input.Food < 0 || input.Gold+input.Food <= 0 {
+		return models.CaravanOutput{}, &messages.InvalidCaravanError{Reason: "cargo must be positive"}
+	}
+	if input.CityId == input.TargetCityId {
+		return models.CaravanOutput{}, &messages.InvalidCaravanError{Reason: "target must be another city"}
+	}
+
+	city, err := GetCity(input.CityId)
+	if err != nil {
+		log.Printf("Error sending caravan: %s", err)
+		return models.CaravanOutput{}, err
+	}
+	target, err := GetCity(input.TargetCityId)
+	if err != nil {
+		log.Printf("Error sending caravan: %s", err)
+		return models.CaravanOutput{}, err
+	}
+	if target.Owner == "" {
+		return models.CaravanOutput{}, &messages.InvalidCaravanError{Reason: "target city has no owner"}
+	}
+
+	user, err := GetUser(userId)
+	if err != nil {
+		log.Printf("Error sending caravan: %s", err)
+		return models.CaravanOutput{}, err
+	}
+	if target.Owner != userId && !slices.Contains(user.Allies, target.Owner) {
+		return models.CaravanOutput{}, &messages.NotAlliedError{UserId: userId, AllyId: target.Owner}
+	}
+
+	// check the route before anything is charged
+	from := getCityCenter(city)
+	to := getCityCenter(target)
+	_, err = pathfinding.FindPath(pathfinding.Traveller{Owner: userId, Allies: user.Allies}, from, to)
+	if err != nil {
+		log.Printf("Error sending caravan: %s", err)
+		return models.CaravanOutput{}, err
+	}
+
+	getUserPIDResponse, err := actors.Request[messages.GetUserPIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetUserPIDMessage{
+		UserId: userId,
+	})
+	if err != nil {
+		log.Printf("Error sending caravan: %s", err)
+		return models.CaravanOutput{}, err
+	}
+	if getUserPIDResponse.PID == nil {
+		return models.CaravanOutput{}, &messages.UserNotFoundError{UserId: userId}
+	}
+	spendResponse, err := actors.Request[messages.SpendResourcesResponseMessage](system.Root, getUserPIDResponse.PID, messages.SpendResourcesMessage{
+		Gold:   input.Gold,
+		Food:   input.Food,
+		Reason: constants.LEDGER_REASON_CARAVAN,
+		Source: city.CityId,
+	})
+	if err != nil {
+		log.Printf("Error sending caravan: %s", err)
+		return models.CaravanOutput{}, err
+	}
+	if spendResponse.Error != nil {
+		log.Printf("Error sending caravan: %s", spendResponse.Error)
+		return models.CaravanOutput{}, spendResponse.Error
+	}
+
+	armyId, err := CreateArmy(models.Army{
+		Kind:          constants.ARMY_KIND_CARAVAN,
+		TileX:         from.X,
+		TileY:         from.Y,
+		Owner:         userId,
+		CargoGold:     input.Gold,
+		CargoFood:     input.Food,
+		Recipient:     target.Owner,
+		DestinationId: target.CityId,
+	})
+	if err != nil {
+		log.Printf("Error sending caravan: %s", err)
+		creditResponse, creditErr := actors.Request[messages.CreditResourcesResponseMessage](system.Root, getUserPIDResponse.PID, messages.CreditResourcesMessage{
+			Gold:   input.Gold,
+			Food:   input.Food,
+			Reason: constants.LEDGER_REASON_CARAVAN,
+			Source: city.CityId,
+		})
+		if creditErr == nil {
+			creditErr = creditResponse.Error
+		}
+		if creditErr != nil {
+			log.Printf("Error returning %d gold and %d food to %s: %s", input.Gold, input.Food, userId, creditErr)
+		}
+		return models.CaravanOutput{}, err
+	}
+
+	arrival, err := MarchArmy(armyId, to.X, to.Y)
+	if err != nil {
+		log.Printf("Error sending caravan: %s", err)
+		// the caravan never left, so the cargo goes back to the player
+		unloadErr := unloadCaravan(armyId, userId)
+		if unloadErr != nil {
+			log.Printf("Error returning caravan cargo: %s", unloadErr)
+		}
+		return models.CaravanOutput{}, err
+	}
+
+	caravan, err := GetArmy(armyId)
+	if err != nil {
+		return models.CaravanOutput{}, err
+	}
+	return models.CaravanOutput{
+		Caravan: caravan,
+		Arrival: arrival,
+	}, nil
+}
+
+func unloadCaravan(armyId string, recipient string) error {
+	getArmyPIDResponse, err := actors.Request[messages.GetArmyPIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetArmyPIDMessage{
+		ArmyId: armyId,
+	})
+	if err != nil {
+		return err
+	}
+	if getArmyPIDResponse.PID == nil {
+		return &messages.ArmyNotFoundError{ArmyId: armyId}
+	}
+
+	system.Root.Send(getArmyPIDResponse.PID, messages.UnloadCaravanMessage{
+		Recipient: recipient,
+		Robbed:    false,
+	})
+	return nil
+}
+
+func getCityCenter(city models.City) models.Point {
+	return models.Point{
+		X: city.StartX + city.Size/2,
+		Y: city.StartY + city.Size/2,
+	}
+}
@@ -177,6 +177,7 @@ func CreateCity(city models.CityInput) (*models.City, error) {
 		Name:          city.Name,
 		Population:    constants.INITIAL_PLAYER_CITY_POPULATION,
 		PopulationCap: constants.INITIAL_PLAYER_CITY_POPULATION,
+		TaxRate:       constants.DEFAULT_TAX_RATE,
 		StartX:        startX,
 		StartY:        startY,
 		Size:          city.Size,
@@ -237,6 +238,35 @@ func GetCity(cityId string) (models.City, error) {
 	return getCityResponse.City, nil
 }
 
+func SetCityTaxRate(cityId string, taxRate float64) (models.City, error) {
+	getCityPIDResponse, err := actors.Request[messages.GetCityPIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetCityPIDMessage{
+		CityId: cityId,
+	})
+	if err != nil {
+		log.Printf("Error setting city tax rate: %s", err)
+		return models.City{}, err
+	}
+	if getCityPIDResponse.PID == nil {
+		return models.City{}, &messages.CityNotFoundError{
+			CityId: cityId,
+		}
+	}
+
+	updateTaxRateResponse, err := actors.Request[messages.UpdateCityTaxRateResponseMessage](system.Root, getCityPIDResponse.PID, messages.UpdateCityTaxRateMessage{
+		TaxRate: taxRate,
+	})
+	if err != nil {
+		log.Printf("Error setting city tax rate: %s", err)
+		return models.City{}, err
+	}
+	if updateTaxRateResponse.Error != nil {
+		log.Printf("Error setting city tax rate: %s", updateTaxRateResponse.Error)
+		return models.City{}, updateTaxRateResponse.Error
+	}
+
+	return updateTaxRateResponse.City, nil
+}
+
 func DeleteUserCity(userId string) error {
 	db := database.GetDb()
 
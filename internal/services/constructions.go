@@ -0,0 +1,135 @@
+package services
+
+import (
+	"cityio/internal/actors"
+	"cityio/internal/messages"
+	"cityio/internal/models"
+
+	"log"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+func RestoreConstructions(cityId string, constructions []models.Construction) error {
+	cityPID, err := getCityPID(cityId)
+	if err != nil {
+		log.Printf("Error restoring constructions: %s", err)
+		return err
+	}
+
+	restoreResponse, err := actors.Request[messages.RestoreConstructionsResponseMessage](system.Root, cityPID, messages.RestoreConstructionsMessage{
+		Constructions: constructions,
+	})
+	if err != nil {
+		log.Printf("Error restoring constructions: %s", err)
+		return err
+	}
+	if restoreResponse.Error != nil {
+		log.Printf("Error restoring constructions: %s", restoreResponse.Error)
+		return restoreResponse.Error
+	}
+
+	return nil
+}
+
+// adds the next level of a building to the construction queue of its city
+func queueConstruction(building models.Building) (models.Construction, error) {
+	cityPID, err := getCityPID(building.CityId)
+	if err != nil {
+		log.Printf("Error queueing construction: %s", err)
+		return models.Construction{}, err
+	}
+
+	queueResponse, err := actors.Request[messages.QueueConstructionResponseMessage](system.Root, cityPID, messages.QueueConstructionMessage{
+		Building: building,
+	})
+	if err != nil {
+		log.Printf("Error queueing construction: %s", err)
+		return models.Construction{}, err
+	}
+	if queueResponse.Error != nil {
+		log.Printf("Error queueing construction: %s", queueResponse.Error)
+		return models.Construction{}, queueResponse.Error
+	}
+
+	return queueResponse.Construction, nil
+}
+
+func GetConstructions(cityId string) ([]models.ConstructionOutput, error) {
+	cityPID, err := getCityPID(cityId)
+	if err != nil {
+		log.Printf("Error getting constructions: %s", err)
+		return nil, err
+	}
+
+	getConstructionsResponse, err := actors.Request[messages.GetConstructionsResponseMessage](system.Root, cityPID, messages.GetConstructionsMessage{})
+	if err != nil {
+		log.Printf("Error getting constructions: %s", err)
+		return nil, err
+	}
+
+	return getConstructionsResponse.Constructions, nil
+}
+
+// cancels a construction and the later levels queued for its building, a building
+// whose first level is cancelled is demolished
+func CancelConstruction(cityId string, constructionId string) error {
+	cityPID, err := getCityPID(cityId)
+	if err != nil {
+		log.Printf("Error cancelling construction: %s", err)
+		return err
+	}
+
+	cancelResponse, err := actors.Request[messages.CancelConstructionResponseMessage](system.Root, cityPID, messages.CancelConstructionMessage{
+		ConstructionId: constructionId,
+	})
+	if err != nil {
+		log.Printf("Error cancelling construction: %s", err)
+		return err
+	}
+	if cancelResponse.Error != nil {
+		log.Printf("Error cancelling construction: %s", cancelResponse.Error)
+		return cancelResponse.Error
+	}
+
+	if cancelResponse.DemolishBuildingId != "" {
+		return DemolishBuilding(cancelResponse.DemolishBuildingId)
+	}
+	return nil
+}
+
+func ReorderConstruction(cityId string, constructionId string, position int) error {
+	cityPID, err := getCityPID(cityId)
+	if err != nil {
+		log.Printf("Error reordering construction: %s", err)
+		return err
+	}
+
+	reorderResponse, err := actors.Request[messages.ReorderConstructionResponseMessage](system.Root, cityPID, messages.ReorderConstructionMessage{
+		ConstructionId: constructionId,
+		Position:       position,
+	})
+	if err != nil {
+		log.Printf("Error reordering construction: %s", err)
+		return err
+	}
+	if reorderResponse.Error != nil {
+		log.Printf("Error reordering construction: %s", reorderResponse.Error)
+		return reorderResponse.Error
+	}
+
+	return nil
+}
+
+func getCityPID(cityId string) (*actor.PID, error) {
+	getCityPIDResponse, err := actors.Request[messages.GetCityPIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetCityPIDMessage{
+		CityId: cityId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if getCityPIDResponse.PID == nil {
+		return nil, &messages.CityNotFoundError{CityId: cityId}
+	}
+	return getCityPIDResponse.PID, nil
+}
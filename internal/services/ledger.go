@@ -0,0 +1,45 @@
+package services
+
+import (
+	"cityio/internal/constants"
+	"cityio/internal/models"
+
+	"log"
+	"time"
+)
+
+// GetIncome sums up the ledger of a player by reason, gains and losses of the same
+// reason cancel out, e.g. a cancelled construction and its refund
+func GetIncome(userId string, since time.Time) (models.IncomeOutput, error) {
+	var rows []struct {
+		Resource string
+		Reason   string
+		Total    int64
+	}
+	err := db.Model(&models.LedgerEntry{}).
+		Select("resource, reason, SUM(delta) AS total").
+		Where("user_id = ? AND created_at >= ?", userId, since).
+		Group("resource, reason").
+		Scan(&rows).Error
+	if err != nil {
+		log.Printf("Error getting income: %s", err)
+		return models.IncomeOutput{}, err
+	}
+
+	income := models.IncomeOutput{
+		Since: since,
+		Gold:  make(map[string]int64),
+		Food:  make(map[string]int64),
+	}
+	for _, row := range rows {
+		switch row.Resource {
+		case constants.RESOURCE_GOLD:
+			income.Gold[row.Reason] = row.Total
+			income.NetGold += row.Total
+		case constants.RESOURCE_FOOD:
+			income.Food[row.Reason] = row.Total
+			income.NetFood += row.Total
+		}
+	}
+	return income, nil
+}
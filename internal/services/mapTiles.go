@@ -114,8 +114,10 @@ func GetMapTile(x int, y int) (models.MapTileOutput, error) {
 	return models.MapTileOutput{
 		X:        getMapTileResponse.Tile.X,
 		Y:        getMapTileResponse.Tile.Y,
+		Terrain:  getMapTileResponse.Tile.Terrain,
 		City:     getMapTileResponse.City,
 		Building: getMapTileResponse.Building,
 		Armies:   getMapTileResponse.Armies,
+		Caravans: getMapTileResponse.Caravans,
 	}, nil
 }
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"cityio/internal/actors"
+	"cityio/internal/constants"
+	"cityio/internal/messages"
+	"cityio/internal/models"
+
+	"log"
+)
+
+func RestoreOrders(orders []models.MarketOrder) error {
+	restoreResponse, err := actors.Request[messages.RestoreOrdersResponseMessage](system.Root, actors.GetMarketPID(), messages.RestoreOrdersMessage{
+		Orders: orders,
+	})
+	if err != nil {
+		log.Printf("Error restoring orders: %s", err)
+		return err
+	}
+	if restoreResponse.Error != nil {
+		log.Printf("Error restoring orders: %s", restoreResponse.Error)
+		return restoreResponse.Error
+	}
+
+	return nil
+}
+
+// places a limit order through the market of one of the cities of the player
+func PlaceOrder(order models.MarketOrder) (models.MarketOrderOutput, error) {
+	err := checkMarket(order.CityId)
+	if err != nil {
+		log.Printf("Error placing order: %s", err)
+		return models.MarketOrderOutput{}, err
+	}
+
+	placeResponse, err := actors.Request[messages.PlaceOrderResponseMessage](system.Root, actors.GetMarketPID(), messages.PlaceOrderMessage{
+		Order: order,
+	})
+	if err != nil {
+		log.Printf("Error placing order: %s", err)
+		return models.MarketOrderOutput{}, err
+	}
+	if placeResponse.Error != nil {
+		log.Printf("Error placing order: %s", placeResponse.Error)
+		return models.MarketOrderOutput{}, placeResponse.Error
+	}
+
+	return models.MarketOrderOutput{
+		Order:  placeResponse.Order,
+		Trades: placeResponse.Trades,
+	}, nil
+}
+
+func CancelOrder(orderId string, userId string) error {
+	cancelResponse, err := actors.Request[messages.CancelOrderResponseMessage](system.Root, actors.GetMarketPID(), messages.CancelOrderMessage{
+		OrderId: orderId,
+		UserId:  userId,
+	})
+	if err != nil {
+		log.Printf("Error cancelling order: %s", err)
+		return err
+	}
+	if cancelResponse.Error != nil {
+		log.Printf("Error cancelling order: %s", cancelResponse.Error)
+		return cancelResponse.Error
+	}
+
+	return nil
+}
+
+func GetOrders() ([]models.MarketOrder, error) {
+	getOrdersResponse, err := actors.Request[messages.GetOrdersResponseMessage](system.Root, actors.GetMarketPID(), messages.GetOrdersMessage{})
+	if err != nil {
+		log.Printf("Error getting orders: %s", err)
+		return nil, err
+	}
+
+	return getOrdersResponse.Orders, nil
+}
+
+// latest trades the player took part in
+func GetTrades(userId string, limit int) ([]models.Trade, error) {
+	var trades []models.Trade
+	err := db.Where("buyer = ? OR seller = ?", userId, userId).Order("created_at DESC").Limit(limit).Find(&trades).Error
+	if err != nil {
+		log.Printf("Error getting trades: %s", err)
+		return nil, err
+	}
+
+	return trades, nil
+}
+
+// trading goes through a finished market in the city the order is placed from
+func checkMarket(cityId string) error {
+	cityPID, err := getCityPID(cityId)
+	if err != nil {
+		return err
+	}
+
+	getCityBuildingsResponse, err := actors.Request[messages.GetCityBuildingsResponseMessage](system.Root, cityPID, messages.GetCityBuildingsMessage{})
+	if err != nil {
+		return err
+	}
+	for buildingId, buildingType := range getCityBuildingsResponse.Buildings {
+		if buildingType != constants.BUILDING_TYPE_MARKET {
+			continue
+		}
+		building, err := GetBuilding(buildingId)
+		if err != nil {
+			return err
+		}
+		if building.Level > 0 {
+			return nil
+		}
+	}
+
+	return &messages.MarketRequiredError{CityId: cityId}
+}
@@ -7,6 +7,7 @@ import (
 	"cityio/internal/messages"
 	"cityio/internal/models"
 
+	"errors"
 	"log"
 	"os"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 func RestoreUser(user models.User) error {
@@ -139,8 +141,7 @@ func LoginUser(user models.LoginUserRequest) (models.LoginUserResponse, error) {
 		return models.LoginUserResponse{}, err
 	}
 
-	var capital models.City
-	err = db.Where("owner = ?", account.UserId).First(&capital).Error
+	capital, err := getCapital(account.UserId)
 	if err != nil {
 		return models.LoginUserResponse{}, err
 	}
@@ -150,7 +151,7 @@ func LoginUser(user models.LoginUserRequest) (models.LoginUserResponse, error) {
 		UserId:   account.UserId,
 		Username: account.Username,
 		Email:    account.Email,
-		Capital:  &capital,
+		Capital:  capital,
 	}, nil
 }
 
@@ -168,8 +169,7 @@ func ValidateToken(tokenString string) (models.UserClaims, *models.City, error)
 		return models.UserClaims{}, nil, &messages.InvalidTokenError{}
 	}
 
-	var capital models.City
-	err = db.Where("owner = ?", claims["userId"]).First(&capital).Error
+	capital, err := getCapital(claims["userId"].(string))
 	if err != nil {
 		return models.UserClaims{}, nil, err
 	}
@@ -178,7 +178,20 @@ func ValidateToken(tokenString string) (models.UserClaims, *models.City, error)
 		Username: claims["username"].(string),
 		Email:    claims["email"].(string),
 		UserId:   claims["userId"].(string),
-	}, &capital, nil
+	}, capital, nil
+}
+
+// returns nil if the user has lost their capital
+func getCapital(userId string) (*models.City, error) {
+	var capital models.City
+	err := db.Where("owner = ? AND type = ?", userId, "capital").First(&capital).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &capital, nil
 }
 
 func GetUser(userId string) (models.User, error) {
@@ -199,17 +212,22 @@ func GetUser(userId string) (models.User, error) {
 }
 
 func GetUserAccount(userId string) (models.UserAccountOutput, error) {
-	user, err := GetUser(userId)
+	response, err := actors.Request[messages.GetUserPIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetUserPIDMessage{
+		UserId: userId,
+	})
 	if err != nil {
 		return models.UserAccountOutput{}, err
 	}
+	if response.PID == nil {
+		return models.UserAccountOutput{}, &messages.UserNotFoundError{UserId: userId}
+	}
 
-	return models.UserAccountOutput{
-		Username: user.Username,
-		Gold:     user.Gold,
-		Food:     user.Food,
-		Allies:   user.Allies,
-	}, nil
+	accountResponse, err := actors.Request[messages.GetUserAccountResponseMessage](system.Root, response.PID, messages.GetUserAccountMessage{})
+	if err != nil {
+		return models.UserAccountOutput{}, err
+	}
+
+	return accountResponse.Account, nil
 }
 
 func DeleteUser(userId string) error {
@@ -353,3 +371,29 @@ func RemoveAlliance(user1 string, user2 string) error {
 
 	return nil
 }
+
+func UpdateUserSettings(userId string, settings models.UserSettingsRequest) error {
+	getUserPIDResponse, err := actors.Request[messages.GetUserPIDResponseMessage](system.Root, actors.GetManagerPID(), messages.GetUserPIDMessage{
+		UserId: userId,
+	})
+	if err != nil {
+		log.Printf("Error updating user settings: %s", err)
+		return err
+	}
+	if getUserPIDResponse.PID == nil {
+		return &messages.UserNotFoundError{UserId: userId}
+	}
+
+	updateSettingsResponse, err := actors.Request[messages.UpdateUserSettingsResponseMessage](system.Root, getUserPIDResponse.PID, messages.UpdateUserSettingsMessage{
+		DisableAutoMerge: settings.DisableAutoMerge,
+	})
+	if err != nil {
+		log.Printf("Error updating user settings: %s", err)
+		return err
+	}
+	if updateSettingsResponse.Error != nil {
+		log.Printf("Error updating user settings: %s", updateSettingsResponse.Error)
+		return updateSettingsResponse.Error
+	}
+	return nil
+}
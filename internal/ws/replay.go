@@ -0,0 +1,75 @@
+package ws
+
+import (
+	"cityio/internal/constants"
+	"cityio/internal/messages"
+	"cityio/internal/models"
+
+	"encoding/json"
+	"sync"
+)
+
+// updates pushed to a player, numbered so a client can pick up where it left off.
+// Sequences live in memory only, a restarted server asks every resuming client to resync.
+type history struct {
+	seq    uint64
+	frames [][]byte // the last WS_REPLAY_SIZE updates as they were sent, oldest first
+}
+
+// histories are locked before sessions whenever both are needed
+var (
+	histories      = make(map[string]*history)
+	historiesMutex sync.Mutex
+)
+
+// numbers an already encoded update of the player and keeps it for replay, must hold
+// historiesMutex
+func (history *history) record(message int, data json.RawMessage) ([]byte, error) {
+	frame, err := json.Marshal(&models.WebSocketResponse{
+		Seq:  history.seq + 1,
+		Msg:  message,
+		Data: data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	history.seq++
+	history.frames = append(history.frames, frame)
+	if len(history.frames) > constants.WS_REPLAY_SIZE {
+		history.frames = history.frames[len(history.frames)-constants.WS_REPLAY_SIZE:]
+	}
+	return frame, nil
+}
+
+// queues the updates after lastSeq, or a resync signal when some of them are gone,
+// must hold historiesMutex
+func (history *history) replay(session *Session, lastSeq uint64) error {
+	if lastSeq == history.seq {
+		return nil
+	}
+	oldest := history.seq + 1 - uint64(len(history.frames))
+	if lastSeq > history.seq || lastSeq+1 < oldest {
+		return session.Write(&models.WebSocketResponse{
+			Msg: messages.WS_RESYNC,
+			Data: models.ResyncOutput{
+				LastSeq: lastSeq,
+				Seq:     history.seq,
+			},
+		})
+	}
+
+	for _, frame := range history.frames[lastSeq+1-oldest:] {
+		if err := session.writeFrame(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getHistory(userId string) *history {
+	if _, ok := histories[userId]; !ok {
+		histories[userId] = &history{}
+	}
+	return histories[userId]
+}
@@ -0,0 +1,113 @@
+package ws
+
+import (
+	"cityio/internal/models"
+
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+// areas of the map watched by each session, actors publish changes from their own goroutines
+var (
+	viewports      = make(map[*Session]models.Viewport)
+	viewportsMutex sync.RWMutex
+)
+
+// changes held back from sessions still reading the snapshot of their viewport,
+// locked before sessions and viewports whenever both are needed
+var (
+	pending      = make(map[*Session][][]byte)
+	pendingMutex sync.Mutex
+)
+
+// Subscribe makes the session watch an area of the map, replacing the area watched before.
+// Changes are held back until the snapshot of the area is handed to FinishSubscribe, so
+// the client never applies a snapshot over a newer change.
+func Subscribe(session *Session, viewport models.Viewport) {
+	pendingMutex.Lock()
+	pending[session] = make([][]byte, 0)
+	pendingMutex.Unlock()
+
+	viewportsMutex.Lock()
+	defer viewportsMutex.Unlock()
+	viewports[session] = viewport
+}
+
+// FinishSubscribe queues the snapshot read after Subscribe followed by the changes held
+// back meanwhile. Changes carry the whole state of what they touch, so those already
+// part of the snapshot are harmless to apply again.
+func FinishSubscribe(session *Session, snapshot interface{}) error {
+	pendingMutex.Lock()
+	defer pendingMutex.Unlock()
+	held := pending[session]
+	delete(pending, session)
+
+	frame, err := json.Marshal(snapshot)
+	if err != nil {
+		Unsubscribe(session)
+		return err
+	}
+	if err := session.writeFrame(frame); err != nil {
+		return err
+	}
+	for _, frame := range held {
+		if err := session.writeFrame(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Unsubscribe(session *Session) {
+	viewportsMutex.Lock()
+	defer viewportsMutex.Unlock()
+	delete(viewports, session)
+}
+
+// IsWatched reports whether any session watches part of the area, so publishers can
+// skip building updates nobody receives.
+func IsWatched(area models.Viewport) bool {
+	viewportsMutex.RLock()
+	defer viewportsMutex.RUnlock()
+	for _, viewport := range viewports {
+		if viewport.Overlaps(area) {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish sends a message to every session watching part of the area.
+func Publish(area models.Viewport, message int, data interface{}) {
+	frame, err := json.Marshal(&models.WebSocketResponse{
+		Msg:  message,
+		Data: data,
+	})
+	if err != nil {
+		log.Printf("Error encoding message %d: %s", message, err)
+		return
+	}
+
+	viewportsMutex.RLock()
+	watchers := make([]*Session, 0)
+	for session, viewport := range viewports {
+		if viewport.Overlaps(area) {
+			watchers = append(watchers, session)
+		}
+	}
+	viewportsMutex.RUnlock()
+
+	pendingMutex.Lock()
+	defer pendingMutex.Unlock()
+	for _, session := range watchers {
+		if held, ok := pending[session]; ok {
+			pending[session] = append(held, frame)
+			continue
+		}
+		err := session.writeFrame(frame)
+		if err != nil {
+			log.Printf("Error publishing message %d to %s: %s", message, session.UserId, err)
+		}
+	}
+}
@@ -1,34 +1,184 @@
 package ws
 
 import (
-	"cityio/internal/models"
+	"cityio/internal/constants"
+	"cityio/internal/messages"
 
+	"encoding/json"
 	"log"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
-var connections = make(map[string]*websocket.Conn)
+// Session is a single connection of a player, a player may have several open at once.
+// Frames are only written by the writer goroutine of the session so actors never block
+// on a slow client. They are encoded before being queued, as the data usually belongs
+// to an actor that keeps changing it.
+type Session struct {
+	SessionId string
+	UserId    string
 
-func AddConnection(userId string, conn *websocket.Conn) {
-	connections[userId] = conn
+	conn      *websocket.Conn
+	outbound  chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
-func Send(userId string, message int, data interface{}) error {
-	conn, ok := connections[userId]
-	if !ok {
+// open sessions of each player, written on connect and disconnect and read by every actor
+var (
+	sessions      = make(map[string]map[*Session]struct{})
+	sessionsMutex sync.RWMutex
+)
+
+// AddConnection registers a new session of the player and starts writing to it.
+// A client resuming after a disconnect passes the last update it saw, and gets the
+// updates it missed before any new one. The session must be closed once the
+// connection is done with.
+func AddConnection(userId string, conn *websocket.Conn, lastSeq *uint64) (*Session, error) {
+	session := &Session{
+		SessionId: uuid.New().String(),
+		UserId:    userId,
+		conn:      conn,
+		outbound:  make(chan []byte, constants.WS_SEND_QUEUE_SIZE),
+		done:      make(chan struct{}),
+	}
+	go session.writeLoop()
+
+	// no update may be sent between the replay and the session joining
+	historiesMutex.Lock()
+	defer historiesMutex.Unlock()
+	if lastSeq != nil {
+		if err := getHistory(userId).replay(session, *lastSeq); err != nil {
+			session.Close()
+			return nil, err
+		}
+	}
+
+	sessionsMutex.Lock()
+	if _, ok := sessions[userId]; !ok {
+		sessions[userId] = make(map[*Session]struct{})
+	}
+	sessions[userId][session] = struct{}{}
+	sessionsMutex.Unlock()
+	return session, nil
+}
+
+// Write encodes a frame and queues it for the session without waiting for the client.
+// A session whose queue is full is closed, the client has to reconnect.
+func (session *Session) Write(message interface{}) error {
+	frame, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return session.writeFrame(frame)
+}
+
+func (session *Session) writeFrame(frame []byte) error {
+	select {
+	case <-session.done:
+		return &messages.SessionClosedError{SessionId: session.SessionId}
+	default:
+	}
+
+	select {
+	case session.outbound <- frame:
 		return nil
+	default:
+		log.Printf("Closing session %s of %s, the client is too slow", session.SessionId, session.UserId)
+		session.Close()
+		return &messages.SessionClosedError{SessionId: session.SessionId}
 	}
+}
 
-	return conn.WriteJSON(&models.WebSocketResponse{
-		Msg:  message,
-		Data: data,
+// Close unregisters the session and closes its connection, it is safe to call more than once.
+func (session *Session) Close() {
+	session.closeOnce.Do(func() {
+		sessionsMutex.Lock()
+		delete(sessions[session.UserId], session)
+		if len(sessions[session.UserId]) == 0 {
+			delete(sessions, session.UserId)
+		}
+		sessionsMutex.Unlock()
+		Unsubscribe(session)
+
+		close(session.done)
 	})
 }
 
+func (session *Session) writeLoop() {
+	// closing the connection also ends the read loop of the session
+	defer session.conn.Close()
+	for {
+		select {
+		case <-session.done:
+			return
+		case frame := <-session.outbound:
+			session.conn.SetWriteDeadline(time.Now().Add(constants.WS_WRITE_TIMEOUT * time.Second))
+			if err := session.conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+				log.Printf("Error writing to session %s of %s: %s", session.SessionId, session.UserId, err)
+				session.Close()
+				return
+			}
+		}
+	}
+}
+
+// Send pushes an update to every open session of the player. Updates sent while the
+// player is offline are kept for a while in case they reconnect.
+func Send(userId string, message int, data interface{}) error {
+	// the payload is encoded before locking, only numbering and queueing are serialized
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error encoding message %d to %s: %s", message, userId, err)
+		return err
+	}
+
+	// held while queueing so every session gets the updates in order, queueing never blocks
+	historiesMutex.Lock()
+	defer historiesMutex.Unlock()
+	frame, err := getHistory(userId).record(message, payload)
+	if err != nil {
+		log.Printf("Error encoding message %d to %s: %s", message, userId, err)
+		return err
+	}
+
+	sessionsMutex.RLock()
+	userSessions := make([]*Session, 0, len(sessions[userId]))
+	for session := range sessions[userId] {
+		userSessions = append(userSessions, session)
+	}
+	sessionsMutex.RUnlock()
+
+	for _, session := range userSessions {
+		err := session.writeFrame(frame)
+		if err != nil {
+			log.Printf("Error sending message %d to %s: %s", message, userId, err)
+		}
+	}
+	return nil
+}
+
 func Broadcast(message interface{}) {
-	for _, conn := range connections {
-		if err := conn.WriteJSON(message); err != nil {
+	frame, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Error encoding broadcast: %s", err)
+		return
+	}
+
+	sessionsMutex.RLock()
+	allSessions := make([]*Session, 0)
+	for _, userSessions := range sessions {
+		for session := range userSessions {
+			allSessions = append(allSessions, session)
+		}
+	}
+	sessionsMutex.RUnlock()
+
+	for _, session := range allSessions {
+		if err := session.writeFrame(frame); err != nil {
 			log.Printf("Error broadcasting message: %s", err)
 		}
 	}